@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event is the type of an audit log entry.
+type Event string
+
+const (
+	Login         Event = "login"
+	LoginFailed   Event = "login_failed"
+	Logout        Event = "logout"
+	RoomCreate    Event = "room_create"
+	RoomJoin      Event = "room_join"
+	RoomLeave     Event = "room_leave"
+	RoomClose     Event = "room_close"
+	ShareStart    Event = "share_start"
+	ShareStop     Event = "share_stop"
+	SessionCreate Event = "session_create"
+	SessionClose  Event = "session_close"
+	Admin         Event = "admin"
+)
+
+// Actor identifies a user.
+type Actor struct {
+	// Name is the display name, it can be chosen freely by the user.
+	Name string `json:"name,omitempty"`
+	// Account is the name of the logged-in user.
+	Account string `json:"account,omitempty"`
+	IP      string `json:"ip,omitempty"`
+}
+
+// Entry is a single line in the audit log. For session events, User is the viewer and Streamer the user sharing
+// the screen.
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Event    Event     `json:"event"`
+	User     Actor     `json:"user"`
+	Room     string    `json:"room,omitempty"`
+	Session  string    `json:"session,omitempty"`
+	Streamer *Actor    `json:"streamer,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+var (
+	lock    sync.Mutex
+	encoder *json.Encoder
+)
+
+// Init initializes the audit log. The destination is either stdout, a file path or empty to disable the audit log.
+// Files are rotated when they exceed maxSizeMB, 0 disables rotation.
+func Init(destination string, maxSizeMB, maxBackups int) error {
+	var out io.Writer
+	switch destination {
+	case "":
+		return nil
+	case "stdout":
+		out = os.Stdout
+	default:
+		file, err := openRotating(destination, int64(maxSizeMB)*1024*1024, maxBackups)
+		if err != nil {
+			return err
+		}
+		out = file
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	encoder = json.NewEncoder(out)
+	log.Info().Str("destination", destination).Msg("Audit log enabled")
+	return nil
+}
+
+// Log writes the entry to the audit log.
+func Log(entry Entry) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if encoder == nil {
+		return
+	}
+
+	entry.Time = time.Now().UTC()
+	if err := encoder.Encode(entry); err != nil {
+		log.Err(err).Msg("could not write audit log")
+	}
+}
@@ -7,16 +7,27 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/rs/zerolog/log"
+	"github.com/screego/server/audit"
+	"github.com/screego/server/util"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Users struct {
 	Lookup         map[string]string
-	store          sessions.Store
+	store          atomic.Pointer[sessions.CookieStore]
 	sessionTimeout int
+	trustProxy     bool
+
+	secretLock sync.Mutex
+	secrets    [][]byte
+	rotation   int
 }
 
 type UserPW struct {
@@ -45,12 +56,16 @@ func read(r io.Reader) ([]UserPW, error) {
 	return result, nil
 }
 
-func ReadPasswordsFile(path string, secret []byte, sessionTimeout int) (*Users, error) {
+// ReadPasswordsFile reads the users file. Session cookies are signed with the first secret, all secrets are accepted
+// for validation.
+func ReadPasswordsFile(path string, secrets [][]byte, sessionTimeout int, trustProxy bool) (*Users, error) {
 	users := &Users{
 		Lookup:         map[string]string{},
 		sessionTimeout: sessionTimeout,
-		store:          sessions.NewCookieStore(secret),
+		trustProxy:     trustProxy,
+		secrets:        secrets,
 	}
+	users.store.Store(cookieStore(secrets))
 	if path == "" {
 		log.Info().Msg("Users file not specified")
 		return users, nil
@@ -73,12 +88,44 @@ func ReadPasswordsFile(path string, secret []byte, sessionTimeout int) (*Users,
 	return users, nil
 }
 
+// RotateSecrets replaces the secrets of the session cookies. Cookies signed with the previous secrets stay valid
+// during the grace period.
+func (u *Users) RotateSecrets(secrets [][]byte, grace time.Duration) {
+	u.secretLock.Lock()
+	defer u.secretLock.Unlock()
+
+	u.store.Store(cookieStore(slices.Concat(secrets, u.secrets)))
+	u.secrets = secrets
+	u.rotation++
+	rotation := u.rotation
+	time.AfterFunc(grace, func() {
+		u.secretLock.Lock()
+		defer u.secretLock.Unlock()
+		if u.rotation == rotation {
+			u.store.Store(cookieStore(u.secrets))
+		}
+	})
+}
+
+// cookieStore signs cookies with the first secret, gorilla tries all secrets in order when decoding.
+func cookieStore(secrets [][]byte) *sessions.CookieStore {
+	var keyPairs [][]byte
+	for _, secret := range secrets {
+		// a nil block key disables encryption.
+		keyPairs = append(keyPairs, secret, nil)
+	}
+	return sessions.NewCookieStore(keyPairs...)
+}
+
 type Response struct {
 	Message string `json:"message"`
 }
 
 func (u *Users) CurrentUser(r *http.Request) (string, bool) {
-	s, _ := u.store.Get(r, "user")
+	if user, ok := u.clientCertificateUser(r); ok {
+		return user, true
+	}
+	s, _ := u.store.Load().Get(r, "user")
 	user, ok := s.Values["user"].(string)
 	if !ok {
 		return "guest", ok
@@ -86,10 +133,34 @@ func (u *Users) CurrentUser(r *http.Request) (string, bool) {
 	return user, ok
 }
 
+// clientCertificateUser returns the common name of a verified TLS client certificate if it's a user of the users
+// file. The CA may sign certificates for other services, therefore other names aren't accepted.
+func (u *Users) clientCertificateUser(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return "", false
+	}
+	name := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	_, exists := u.Lookup[name]
+	return name, exists
+}
+
+// Logout removes the session cookie. Users of TLS client certificates are logged in with every request, for them
+// logout isn't possible.
 func (u *Users) Logout(w http.ResponseWriter, r *http.Request) {
-	session := sessions.NewSession(u.store, "user")
+	if _, ok := u.clientCertificateUser(r); ok {
+		w.WriteHeader(400)
+		_ = json.NewEncoder(w).Encode(&Response{
+			Message: "logged in via TLS client certificate, logout isn't possible",
+		})
+		return
+	}
+	if user, ok := u.CurrentUser(r); ok {
+		u.audit(audit.Logout, user, r)
+	}
+	store := u.store.Load()
+	session := sessions.NewSession(store, "user")
 	session.IsNew = true
-	if err := u.store.Save(r, w, session); err != nil {
+	if err := store.Save(r, w, session); err != nil {
 		w.WriteHeader(500)
 		_ = json.NewEncoder(w).Encode(&Response{
 			Message: err.Error(),
@@ -104,6 +175,7 @@ func (u *Users) Authenticate(w http.ResponseWriter, r *http.Request) {
 	pass := r.FormValue("pass")
 
 	if !u.Validate(user, pass) {
+		u.audit(audit.LoginFailed, user, r)
 		w.WriteHeader(401)
 		_ = json.NewEncoder(w).Encode(&Response{
 			Message: "could not authenticate",
@@ -111,24 +183,33 @@ func (u *Users) Authenticate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := sessions.NewSession(u.store, "user")
+	store := u.store.Load()
+	session := sessions.NewSession(store, "user")
 	session.IsNew = true
 	session.Options.MaxAge = u.sessionTimeout
 	session.Values["user"] = user
-	if err := u.store.Save(r, w, session); err != nil {
+	if err := store.Save(r, w, session); err != nil {
 		w.WriteHeader(500)
 		_ = json.NewEncoder(w).Encode(&Response{
 			Message: err.Error(),
 		})
 		return
 	}
+	u.audit(audit.Login, user, r)
 	w.WriteHeader(200)
 	_ = json.NewEncoder(w).Encode(&Response{
 		Message: "authenticated",
 	})
 }
 
-func (u Users) Validate(user, password string) bool {
+func (u *Users) audit(event audit.Event, user string, r *http.Request) {
+	audit.Log(audit.Entry{
+		Event: event,
+		User:  audit.Actor{Account: user, IP: util.RemoteIP(r, u.trustProxy).String()},
+	})
+}
+
+func (u *Users) Validate(user, password string) bool {
 	realPassword, exists := u.Lookup[user]
 	return exists && bcrypt.CompareHashAndPassword([]byte(realPassword), []byte(password)) == nil
 }
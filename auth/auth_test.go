@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUsers_Secrets(t *testing.T) {
+	old := newUsers(t, []byte("old-secret"))
+	request := login(t, old)
+
+	users := newUsers(t, []byte("new-secret"), []byte("old-secret"))
+	user, ok := users.CurrentUser(request())
+	assert.True(t, ok, "all secrets are accepted for validation")
+	assert.Equal(t, "user1", user)
+
+	_, ok = newUsers(t, []byte("new-secret")).CurrentUser(request())
+	assert.False(t, ok)
+
+	_, ok = old.CurrentUser(login(t, users)())
+	assert.False(t, ok, "the first secret is used for signing")
+}
+
+func TestUsers_RotateSecrets(t *testing.T) {
+	users := newUsers(t, []byte("old-secret"))
+	request := login(t, users)
+
+	users.RotateSecrets([][]byte{[]byte("new-secret")}, 50*time.Millisecond)
+	user, ok := users.CurrentUser(request())
+	assert.True(t, ok, "the previous secret is valid during the grace period")
+	assert.Equal(t, "user1", user)
+
+	assert.Eventually(t, func() bool {
+		_, ok := users.CurrentUser(request())
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUsers_CurrentUser_clientCertificate(t *testing.T) {
+	users := newUsers(t, []byte("secret"))
+	r := httptest.NewRequest("GET", "/config", nil)
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "user1"}}}}}
+
+	user, ok := users.CurrentUser(r)
+	assert.True(t, ok)
+	assert.Equal(t, "user1", user)
+
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "user2"}}}}}
+	_, ok = users.CurrentUser(r)
+	assert.False(t, ok, "users which aren't in the users file are ignored")
+
+	r.TLS = &tls.ConnectionState{}
+	_, ok = users.CurrentUser(r)
+	assert.False(t, ok, "unverified certificates are ignored")
+}
+
+func TestUsers_Logout_clientCertificate(t *testing.T) {
+	users := newUsers(t, []byte("secret"))
+	r := httptest.NewRequest("POST", "/logout", nil)
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "user1"}}}}}
+
+	recorder := httptest.NewRecorder()
+	users.Logout(recorder, r)
+	assert.Equal(t, 400, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "logged in via TLS client certificate")
+	assert.Empty(t, recorder.Result().Cookies())
+}
+
+func newUsers(t *testing.T, secrets ...[]byte) *Users {
+	users, err := ReadPasswordsFile("", secrets, 0, false)
+	require.NoError(t, err)
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+	users.Lookup["user1"] = string(hash)
+	return users
+}
+
+// login authenticates user1 and returns a function creating requests with the session cookie.
+func login(t *testing.T, users *Users) func() *http.Request {
+	form := url.Values{"user": {"user1"}, "pass": {"pass"}}.Encode()
+	r := httptest.NewRequest("POST", "/login", strings.NewReader(form))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	users.Authenticate(recorder, r)
+	require.Equal(t, 200, recorder.Code)
+
+	return func() *http.Request {
+		r := httptest.NewRequest("GET", "/config", nil)
+		for _, cookie := range recorder.Result().Cookies() {
+			r.AddCookie(cookie)
+		}
+		return r
+	}
+}
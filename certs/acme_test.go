@@ -0,0 +1,165 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/screego/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+func TestNew_acme(t *testing.T) {
+	ca := newACMEServer(t)
+
+	manager, err := New(config.Config{
+		ACMEDomains:      []string{"screego.test"},
+		ACMECacheDir:     t.TempDir(),
+		ACMEDirectoryURL: ca.URL + "/directory",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, manager.HTTPHandler())
+
+	hello := &tls.ClientHelloInfo{
+		ServerName:       "screego.test",
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedCurves:  []tls.CurveID{tls.CurveP256},
+		CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	}
+
+	tlsConfig := manager.TLSConfig()
+	assert.Contains(t, tlsConfig.NextProtos, acme.ALPNProto)
+	cert, err := tlsConfig.GetCertificate(hello)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"screego.test"}, cert.Leaf.DNSNames)
+	assert.Len(t, cert.Certificate, 2, "the chain contains the ca")
+
+	turnConfig := manager.TURNConfig()
+	assert.Nil(t, turnConfig.NextProtos)
+	turnCert, err := turnConfig.GetCertificate(hello)
+	require.NoError(t, err)
+	assert.Equal(t, cert.Certificate, turnCert.Certificate, "the TURN listener uses the same certificate")
+}
+
+// newACMEServer starts a minimal ACME server. Orders are ready without challenges and JWS signatures aren't
+// verified, it only covers the requests of the autocert issuance.
+func newACMEServer(t *testing.T) *httptest.Server {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	var server *httptest.Server
+	var leafDER []byte
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, status int, location string, v any) {
+		if location != "" {
+			w.Header().Set("Location", server.URL+location)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("/directory", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, 200, "", map[string]any{
+			"newNonce":   server.URL + "/nonce",
+			"newAccount": server.URL + "/account",
+			"newOrder":   server.URL + "/order",
+			"revokeCert": server.URL + "/revoke",
+			"keyChange":  server.URL + "/key-change",
+		})
+	})
+	mux.HandleFunc("/nonce", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, 201, "/account/1", map[string]any{"status": "valid"})
+	})
+	order := func(status string) map[string]any {
+		return map[string]any{
+			"status":         status,
+			"identifiers":    []map[string]string{{"type": "dns", "value": "screego.test"}},
+			"authorizations": []string{},
+			"finalize":       server.URL + "/finalize",
+			"certificate":    server.URL + "/certificate",
+		}
+	}
+	mux.HandleFunc("/order", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, 201, "/order/1", order("ready"))
+	})
+	mux.HandleFunc("/finalize", func(w http.ResponseWriter, r *http.Request) {
+		csr, err := finalizeCSR(r)
+		if !assert.NoError(t, err) {
+			w.WriteHeader(400)
+			return
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			DNSNames:     csr.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		leafDER, err = x509.CreateCertificate(rand.Reader, template, caTemplate, csr.PublicKey, caKey)
+		assert.NoError(t, err)
+		writeJSON(w, 200, "/order/1", order("valid"))
+	})
+	mux.HandleFunc("/certificate", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+		_ = pem.Encode(w, &pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	})
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Replay-Nonce", base64.RawURLEncoding.EncodeToString(big.NewInt(time.Now().UnixNano()).Bytes()))
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// finalizeCSR returns the CSR of a finalize request.
+func finalizeCSR(r *http.Request) (*x509.CertificateRequest, error) {
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		return nil, err
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		return nil, err
+	}
+	var request struct {
+		CSR string `json:"csr"`
+	}
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, err
+	}
+	der, err := base64.RawURLEncoding.DecodeString(request.CSR)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificateRequest(der)
+}
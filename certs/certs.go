@@ -0,0 +1,129 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/screego/server/config"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Manager provides the certificates of the http and TURN TLS listeners, either from files or obtained via ACME.
+type Manager struct {
+	acme      *autocert.Manager
+	tlsConfig *tls.Config
+}
+
+const reloadInterval = 30 * time.Second
+
+// New creates the Manager for the configured certificates, it's nil if TLS is disabled.
+func New(conf config.Config) (*Manager, error) {
+	manager, err := newManager(conf)
+	if manager == nil || err != nil {
+		return nil, err
+	}
+
+	manager.tlsConfig.MinVersion = conf.TLSMinVersionParsed
+	manager.tlsConfig.CipherSuites = conf.TLSCipherSuitesParsed
+	if conf.TLSClientAuthParsed != tls.NoClientCert {
+		pool, err := clientCAs(conf.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		manager.tlsConfig.ClientAuth = conf.TLSClientAuthParsed
+		manager.tlsConfig.ClientCAs = pool
+		if manager.acme != nil {
+			manager.tlsConfig.GetConfigForClient = acmeWithoutClientAuth(manager.tlsConfig)
+		}
+	}
+	return manager, nil
+}
+
+func newManager(conf config.Config) (*Manager, error) {
+	if len(conf.ACMEDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(conf.ACMEDomains...),
+			Cache:      autocert.DirCache(conf.ACMECacheDir),
+			Email:      conf.ACMEEmail,
+		}
+		if conf.ACMEDirectoryURL != "" {
+			manager.Client = &acme.Client{DirectoryURL: conf.ACMEDirectoryURL}
+		}
+		log.Info().Strs("domains", conf.ACMEDomains).Msg("Obtain TLS certificates via ACME")
+		return &Manager{acme: manager, tlsConfig: manager.TLSConfig()}, nil
+	}
+
+	if conf.TLSCertFile != "" || conf.TLSKeyFile != "" {
+		file, err := loadFileCertificate(conf.TLSCertFile, conf.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		go file.watch(reloadInterval)
+		return &Manager{tlsConfig: &tls.Config{GetCertificate: file.GetCertificate}}, nil
+	}
+
+	return nil, nil
+}
+
+// TLSConfig returns the config for the http listener, it's nil if TLS is disabled.
+// The config contains the ALPN protocol of the ACME TLS-ALPN-01 challenge.
+func (m *Manager) TLSConfig() *tls.Config {
+	if m == nil {
+		return nil
+	}
+	return m.tlsConfig
+}
+
+// TURNConfig returns the config for the TURN TLS listener, it's nil if TLS is disabled.
+func (m *Manager) TURNConfig() *tls.Config {
+	if m == nil {
+		return nil
+	}
+	turnConfig := m.tlsConfig.Clone()
+	turnConfig.NextProtos = nil
+	// browsers don't send client certificates to TURN servers.
+	turnConfig.ClientAuth = tls.NoClientCert
+	turnConfig.ClientCAs = nil
+	turnConfig.GetConfigForClient = nil
+	return turnConfig
+}
+
+// HTTPHandler answers ACME HTTP-01 challenges, other requests are redirected to https.
+func (m *Manager) HTTPHandler() http.Handler {
+	return m.acme.HTTPHandler(nil)
+}
+
+// acmeWithoutClientAuth disables client authentication for TLS-ALPN-01 challenges, the ACME server doesn't send a
+// client certificate.
+func acmeWithoutClientAuth(tlsConfig *tls.Config) func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+	challengeConfig := tlsConfig.Clone()
+	challengeConfig.ClientAuth = tls.NoClientCert
+	challengeConfig.ClientCAs = nil
+	return func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		if slices.Contains(hello.SupportedProtos, acme.ALPNProto) {
+			return challengeConfig, nil
+		}
+		// nil keeps the original config.
+		return nil, nil
+	}
+}
+
+func clientCAs(file string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("%s doesn't contain a PEM encoded certificate", file)
+	}
+	return pool, nil
+}
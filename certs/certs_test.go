@@ -0,0 +1,36 @@
+package certs
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"testing"
+
+	"github.com/screego/server/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/acme"
+)
+
+func TestNew_acmeChallengeWithoutClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	writeCertificate(t, caFile, filepath.Join(dir, "ca.key"), "ca")
+
+	manager, err := New(config.Config{
+		ACMEDomains:         []string{"screego.example.org"},
+		ACMECacheDir:        dir,
+		TLSClientAuthParsed: tls.RequireAndVerifyClientCert,
+		TLSClientCAFile:     caFile,
+	})
+	require.NoError(t, err)
+	tlsConfig := manager.TLSConfig()
+	assert.Equal(t, tls.RequireAndVerifyClientCert, tlsConfig.ClientAuth)
+
+	challenge, err := tlsConfig.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{acme.ALPNProto}})
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, challenge.ClientAuth)
+
+	browser, err := tlsConfig.GetConfigForClient(&tls.ClientHelloInfo{SupportedProtos: []string{"h2", "http/1.1"}})
+	require.NoError(t, err)
+	assert.Nil(t, browser, "the client certificate is required")
+}
@@ -0,0 +1,70 @@
+package certs
+
+import (
+	"crypto/tls"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// fileCertificate is a certificate loaded from files. The files are checked for changes in an interval, so that
+// renewed certificates are used without a restart.
+type fileCertificate struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+	modTime  time.Time
+}
+
+func loadFileCertificate(certFile, keyFile string) (*fileCertificate, error) {
+	file := &fileCertificate{certFile: certFile, keyFile: keyFile}
+	if err := file.load(); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (f *fileCertificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return f.cert.Load(), nil
+}
+
+func (f *fileCertificate) load() error {
+	modTime := f.lastModified()
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return err
+	}
+	f.cert.Store(&cert)
+	f.modTime = modTime
+	return nil
+}
+
+// lastModified returns the latest modification time of both files.
+func (f *fileCertificate) lastModified() time.Time {
+	var latest time.Time
+	for _, name := range []string{f.certFile, f.keyFile} {
+		if info, err := os.Stat(name); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+func (f *fileCertificate) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if f.lastModified().Equal(f.modTime) {
+			continue
+		}
+		// cert-manager and similar tools may write the files one after another, an invalid pair is retried on the
+		// next tick.
+		if err := f.load(); err != nil {
+			log.Warn().Err(err).Str("cert", f.certFile).Str("key", f.keyFile).Msg("Cannot reload TLS certificate, keeping the current certificate")
+			continue
+		}
+		log.Info().Str("cert", f.certFile).Msg("Reloaded TLS certificate")
+	}
+}
@@ -15,6 +15,8 @@ func Run(version, commitHash string) {
 		Commands: []cli.Command{
 			serveCmd(version),
 			hashCmd,
+			configCmd,
+			secretCmd,
 		},
 	}
 	err := app.Run(os.Args)
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/screego/server/config"
+	"github.com/screego/server/config/ipdns"
+	"github.com/screego/server/logger"
+	"github.com/urfave/cli"
+)
+
+var configCmd = cli.Command{
+	Name:  "config",
+	Usage: "Validate or print the configuration",
+	Subcommands: []cli.Command{
+		{
+			Name:  "check",
+			Usage: "Validate the configuration without starting the server",
+			Flags: []cli.Flag{
+				configFlag,
+				&cli.BoolFlag{Name: "network", Usage: "check that the external ip and the TURN port are reachable"},
+			},
+			Action: func(ctx *cli.Context) {
+				conf := loadConfig(ctx.String("config"))
+
+				if ctx.Bool("network") && !checkNetwork(conf) {
+					os.Exit(1)
+				}
+				fmt.Println("Config is valid")
+			},
+		},
+		{
+			Name:  "print",
+			Usage: "Print the effective configuration with the source of each value, secrets are redacted",
+			Flags: []cli.Flag{configFlag},
+			Action: func(ctx *cli.Context) {
+				conf := loadConfig(ctx.String("config"))
+				if err := config.Print(os.Stdout, conf); err != nil {
+					log.Fatal().Err(err).Msg("could not print config")
+				}
+			},
+		},
+	},
+}
+
+// loadConfig loads the config and exits on errors.
+func loadConfig(file string) config.Config {
+	conf, errs := config.Get(file)
+	logger.Init(conf.LogLevel.AsZeroLogLevel())
+
+	exit := false
+	for _, err := range errs {
+		log.WithLevel(err.Level).Msg(err.Msg)
+		exit = exit || err.Level == zerolog.FatalLevel || err.Level == zerolog.PanicLevel
+	}
+	if exit {
+		os.Exit(1)
+	}
+	return conf
+}
+
+func checkNetwork(conf config.Config) bool {
+	v4, v6, err := conf.TurnIPProvider.Get()
+	if err != nil {
+		// error is already logged by .Get()
+		return false
+	}
+
+	ok := true
+	listenV4, listenV6 := true, true
+	if !conf.TurnExternal {
+		host, _, _ := net.SplitHostPort(conf.TurnAddress)
+		if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+			listenV4, listenV6 = ip.To4() != nil, ip.To4() == nil
+		}
+	}
+
+	ips := []net.IP{v4, v6}
+	listenAddresses := []string{conf.TurnAddress, conf.TurnAddress}
+	if len(conf.TurnRelayIPsParsed) > 0 {
+		// TURN only listens on the relay ips, the relay and its external ip are of the same address family.
+		ips, listenAddresses = nil, nil
+		for _, relayIP := range conf.TurnRelayIPsParsed {
+			ips = append(ips, relayIP.External)
+			listenAddresses = append(listenAddresses, net.JoinHostPort(relayIP.Relay.String(), conf.TurnPort))
+		}
+	}
+
+	for i, ip := range ips {
+		if ip == nil {
+			continue
+		}
+		logger := log.With().Str("ip", ip.String()).Logger()
+		if ip.IsPrivate() || ip.IsLoopback() || !ip.IsGlobalUnicast() {
+			logger.Warn().Msg("External ip isn't a public address, it's only reachable from the local network")
+		}
+		if len(conf.TurnRelayIPsParsed) == 0 && ((ip.To4() != nil && !listenV4) || (ip.To4() == nil && !listenV6)) {
+			logger.Error().Str("turnAddress", conf.TurnAddress).Msg("TURN doesn't listen on the address family of the external ip")
+			ok = false
+		}
+
+		if conf.TurnPort == "" {
+			logger.Warn().Str("turnAddress", conf.TurnAddress).Msg("TURN uses a systemd socket, its port is only known when started via socket activation, skipping the check")
+			continue
+		}
+
+		address := net.JoinHostPort(ip.String(), conf.TurnPort)
+		stun := &ipdns.STUN{Servers: []string{address}, Timeout: 3 * time.Second}
+		if _, _, err := stun.Get(); err == nil {
+			logger.Info().Str("addr", address).Msg("TURN is reachable")
+			continue
+		}
+
+		if !conf.TurnExternal && canListen(listenAddresses[i]) {
+			logger.Warn().Str("addr", address).Msg("TURN isn't running, start screego to check if the port is reachable")
+			continue
+		}
+		logger.Error().Str("addr", address).Msg("TURN is not reachable, check the firewall and port forwarding")
+		ok = false
+	}
+	return ok
+}
+
+// canListen checks if the udp address is free.
+func canListen(address string) bool {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
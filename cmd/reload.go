@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/screego/server/audit"
+	"github.com/screego/server/config"
+	"github.com/screego/server/logger"
+	"github.com/screego/server/router"
+)
+
+// reloader reloads the config, only settings which are safe to change at runtime are applied.
+func reloader(live *config.Live) router.Reload {
+	return func(actor audit.Actor) ([]string, error) {
+		changes, logs, err := live.Reload()
+		for _, l := range logs {
+			level := l.Level
+			if level == zerolog.FatalLevel || level == zerolog.PanicLevel {
+				level = zerolog.ErrorLevel
+			}
+			log.WithLevel(level).Msg(l.Msg)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Config reload failed, keeping the current config")
+			return nil, err
+		}
+
+		logger.SetLevel(live.Get().LogLevel.AsZeroLogLevel())
+		log.Info().Strs("changes", changes).Msg("Config reloaded")
+		audit.Log(audit.Entry{
+			Event:  audit.Admin,
+			User:   actor,
+			Detail: "config reload: " + strings.Join(changes, ", "),
+		})
+		return changes, nil
+	}
+}
+
+// reloadOnSignal reloads the config on SIGHUP.
+func reloadOnSignal(reload router.Reload) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			_, _ = reload(audit.Actor{Name: "SIGHUP"})
+		}
+	}()
+}
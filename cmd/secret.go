@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/screego/server/auth"
+	"github.com/screego/server/config"
+	"github.com/screego/server/logger"
+	"github.com/screego/server/turn"
+	"github.com/urfave/cli"
+)
+
+var secretCmd = cli.Command{
+	Name:  "secret",
+	Usage: "Manage secrets",
+	Subcommands: []cli.Command{
+		{
+			Name:  "generate",
+			Usage: "Generate a random secret for SCREEGO_SECRET",
+			Flags: []cli.Flag{
+				&cli.IntFlag{Name: "bytes", Value: 32, Usage: "Amount of random bytes, at least 32"},
+			},
+			Action: func(ctx *cli.Context) {
+				logger.Init(zerolog.ErrorLevel)
+				size := ctx.Int("bytes")
+				if size < 32 {
+					log.Fatal().Int("bytes", size).Msg("--bytes must be at least 32")
+				}
+				secret := make([]byte, size)
+				if _, err := rand.Read(secret); err != nil {
+					log.Fatal().Err(err).Msg("could not generate secret")
+				}
+				fmt.Println(base64.RawURLEncoding.EncodeToString(secret))
+			},
+		},
+	},
+}
+
+const secretFileInterval = 30 * time.Second
+
+// watchSecretFiles applies rotated secret files without a restart.
+func watchSecretFiles(conf config.Config, users *auth.Users, tServer turn.Server) {
+	if conf.SecretFile != "" {
+		config.WatchSecretFile(conf.SecretFile, secretFileInterval, func(secret string) {
+			users.RotateSecrets(config.Secrets{[]byte(secret)}, conf.SecretRotationGracePeriod)
+		})
+	}
+	if external, ok := tServer.(*turn.ExternalServer); ok && conf.TurnExternalSecretFile != "" {
+		config.WatchSecretFile(conf.TurnExternalSecretFile, secretFileInterval, external.SetSecret)
+	}
+}
@@ -1,59 +1,136 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"time"
 
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/screego/server/audit"
 	"github.com/screego/server/auth"
+	"github.com/screego/server/certs"
 	"github.com/screego/server/config"
-	"github.com/screego/server/logger"
 	"github.com/screego/server/router"
 	"github.com/screego/server/server"
+	"github.com/screego/server/tracing"
 	"github.com/screego/server/turn"
 	"github.com/screego/server/ws"
 	"github.com/urfave/cli"
 )
 
+var configFlag = &cli.StringFlag{
+	Name:   "config",
+	Usage:  "YAML config file, environment variables take precedence",
+	EnvVar: "SCREEGO_CONFIG",
+}
+
 func serveCmd(version string) cli.Command {
 	return cli.Command{
 		Name: "serve",
+		Flags: []cli.Flag{
+			configFlag,
+		},
 		Action: func(ctx *cli.Context) {
-			conf, errs := config.Get()
-			logger.Init(conf.LogLevel.AsZeroLogLevel())
-
-			exit := false
-			for _, err := range errs {
-				log.WithLevel(err.Level).Msg(err.Msg)
-				exit = exit || err.Level == zerolog.FatalLevel || err.Level == zerolog.PanicLevel
-			}
-			if exit {
-				os.Exit(1)
-			}
+			conf := loadConfig(ctx.String("config"))
+			live := config.NewLive(conf, ctx.String("config"))
 
 			if _, _, err := conf.TurnIPProvider.Get(); err != nil {
 				// error is already logged by .Get()
 				os.Exit(1)
 			}
 
-			users, err := auth.ReadPasswordsFile(conf.UsersFile, conf.Secret, conf.SessionTimeoutSeconds)
+			if err := audit.Init(conf.AuditLog, conf.AuditLogMaxSizeMB, conf.AuditLogMaxBackups); err != nil {
+				log.Fatal().Str("destination", conf.AuditLog).Err(err).Msg("could not initialize audit log")
+			}
+
+			shutdownTracing := func(context.Context) error { return nil }
+			if conf.TracingEndpoint != "" {
+				shutdown, err := tracing.Init(conf.TracingEndpoint, version, conf.TracingSampleRatio)
+				if err != nil {
+					log.Fatal().Str("endpoint", conf.TracingEndpoint).Err(err).Msg("could not initialize tracing")
+				}
+				shutdownTracing = shutdown
+			}
+
+			users, err := auth.ReadPasswordsFile(conf.UsersFile, conf.SessionSecrets, conf.SessionTimeoutSeconds, conf.TrustProxyHeaders)
 			if err != nil {
 				log.Fatal().Str("file", conf.UsersFile).Err(err).Msg("While loading users file")
 			}
 
-			tServer, err := turn.Start(conf)
+			certificates, err := certs.New(conf)
+			if err != nil {
+				log.Fatal().Err(err).Msg("could not load tls certificates")
+			}
+
+			tServer, err := turn.Start(live, certificates.TURNConfig())
 			if err != nil {
 				log.Fatal().Err(err).Msg("could not start turn server")
 			}
 
-			rooms := ws.NewRooms(tServer, users, conf)
+			watchSecretFiles(conf, users, tServer)
+
+			rooms := ws.NewRooms(tServer, users, live)
 
 			go rooms.Start()
 
-			r := router.Router(conf, rooms, users, version)
-			if err := server.Start(r, conf.ServerAddress, conf.TLSCertFile, conf.TLSKeyFile); err != nil {
+			reload := reloader(live)
+			reloadOnSignal(reload)
+
+			drained := make(chan struct{})
+			drain := func() {
+				rooms.Shutdown(conf.ShutdownDrainPeriod)
+				close(drained)
+			}
+
+			if conf.MetricsAddress != "" {
+				internal := router.Internal(conf, rooms, users, reload)
+				go func() {
+					// the internal server stays available while draining, f.ex. for health checks.
+					if err := server.Start(internal, listen(conf, conf.MetricsAddress), nil, func() { <-drained }); err != nil {
+						log.Fatal().Err(err).Msg("internal http server")
+					}
+				}()
+			}
+
+			if conf.ACMEHTTPAddress != "" {
+				go func() {
+					if err := server.Start(certificates.HTTPHandler(), listen(conf, conf.ACMEHTTPAddress), nil, func() { <-drained }); err != nil {
+						log.Fatal().Err(err).Msg("acme http server")
+					}
+				}()
+			}
+
+			r := router.Router(live, rooms, users, version)
+			serverListen := listen(conf, conf.ServerAddress...)
+			serverListen.HTTP2Cleartext = conf.HTTP2Cleartext
+			serverListen.HTTP3Address = conf.HTTP3Address
+			err = server.Start(r, serverListen, certificates.TLSConfig(), drain)
+
+			if err := tServer.Close(); err != nil {
+				log.Warn().Err(err).Msg("could not close turn server")
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(shutdownCtx); err != nil {
+				log.Warn().Err(err).Msg("could not flush traces")
+			}
+
+			if err != nil {
 				log.Fatal().Err(err).Msg("http server")
 			}
 		},
 	}
 }
+
+// listen returns the addresses of a server together with the unix socket settings.
+func listen(conf config.Config, addresses ...string) server.Listen {
+	return server.Listen{
+		Addresses: addresses,
+		UnixSocket: &server.UnixSocket{
+			Mode: conf.UnixSocketModeParsed,
+			UID:  conf.UnixSocketUID,
+			GID:  conf.UnixSocketGID,
+		},
+	}
+}
@@ -2,20 +2,26 @@ package config
 
 import (
 	"crypto/rand"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/rs/zerolog"
 	"github.com/screego/server/config/ipdns"
 	"github.com/screego/server/config/mode"
+	"github.com/screego/server/systemd"
+	"github.com/screego/server/webhook"
 )
 
 var (
@@ -32,6 +38,12 @@ const (
 	AuthModeNone = "none"
 )
 
+const (
+	MetricsAuthNone  = "none"
+	MetricsAuthUsers = "users"
+	MetricsAuthToken = "token"
+)
+
 // Config represents the application configuration.
 type Config struct {
 	LogLevel LogLevel `default:"info" split_words:"true"`
@@ -41,17 +53,46 @@ type Config struct {
 	TLSCertFile string `split_words:"true"`
 	TLSKeyFile  string `split_words:"true"`
 
-	ServerTLS             bool   `split_words:"true"`
-	ServerAddress         string `default:":5050" split_words:"true"`
-	Secret                []byte `split_words:"true"`
-	SessionTimeoutSeconds int    `default:"0" split_words:"true"`
-
-	TurnAddress   string `default:":3478" required:"true" split_words:"true"`
-	TurnPortRange string `split_words:"true"`
-
-	TurnExternalIP     []string `split_words:"true"`
-	TurnExternalPort   string   `default:"3478" split_words:"true"`
-	TurnExternalSecret string   `split_words:"true"`
+	TLSMinVersion         string   `default:"1.2" split_words:"true"`
+	TLSMinVersionParsed   uint16   `ignored:"true"`
+	TLSCipherSuites       []string `split_words:"true"`
+	TLSCipherSuitesParsed []uint16 `ignored:"true"`
+
+	TLSClientAuth       string             `default:"none" split_words:"true"`
+	TLSClientAuthParsed tls.ClientAuthType `ignored:"true"`
+	TLSClientCAFile     string             `envconfig:"TLS_CLIENT_CA_FILE"`
+
+	ACMEDomains      []string `split_words:"true"`
+	ACMECacheDir     string   `split_words:"true"`
+	ACMEEmail        string   `split_words:"true"`
+	ACMEDirectoryURL string   `envconfig:"ACME_DIRECTORY_URL"`
+	ACMEHTTPAddress  string   `envconfig:"ACME_HTTP_ADDRESS"`
+
+	ServerTLS             bool     `split_words:"true"`
+	ServerAddress         []string `default:":5050" split_words:"true"`
+	HTTP2Cleartext        bool     `envconfig:"HTTP2_CLEARTEXT"`
+	HTTP3Address          string   `envconfig:"HTTP3_ADDRESS"`
+	Secret                []byte   `split_words:"true" redact:"true"`
+	Secrets               Secrets  `split_words:"true" redact:"true"`
+	SecretFile            string   `split_words:"true"`
+	SessionSecrets        Secrets  `ignored:"true"`
+	SessionTimeoutSeconds int      `default:"0" split_words:"true"`
+
+	SecretRotationGracePeriod time.Duration `default:"1h" split_words:"true"`
+
+	TurnAddress       string        `default:":3478" required:"true" split_words:"true"`
+	TurnTLSAddress    string        `split_words:"true"`
+	TurnTLSDomain     string        `split_words:"true"`
+	TurnPortRange     string        `split_words:"true"`
+	TurnCredentialTTL time.Duration `default:"10m" split_words:"true"`
+
+	TurnRelayIPs       []string  `split_words:"true"`
+	TurnRelayIPsParsed []RelayIP `ignored:"true"`
+
+	TurnExternalIP         []string `split_words:"true"`
+	TurnExternalPort       string   `default:"3478" split_words:"true"`
+	TurnExternalSecret     string   `split_words:"true" redact:"true"`
+	TurnExternalSecretFile string   `split_words:"true"`
 
 	TrustProxyHeaders  bool     `split_words:"true"`
 	AuthMode           string   `default:"turn" split_words:"true"`
@@ -59,15 +100,48 @@ type Config struct {
 	UsersFile          string   `split_words:"true"`
 	Prometheus         bool     `split_words:"true"`
 
+	UnixSocketMode       string      `split_words:"true"`
+	UnixSocketModeParsed os.FileMode `ignored:"true"`
+	UnixSocketOwner      string      `split_words:"true"`
+	UnixSocketUID        int         `ignored:"true"`
+	UnixSocketGID        int         `ignored:"true"`
+
+	MetricsAddress  string   `split_words:"true"`
+	MetricsAuthMode string   `default:"users" split_words:"true"`
+	MetricsToken    string   `split_words:"true" redact:"true"`
+	AdminUsers      []string `split_words:"true"`
+
+	TracingEndpoint    string  `split_words:"true"`
+	TracingSampleRatio float64 `default:"1" split_words:"true"`
+
+	WebhookURLs   []string `envconfig:"WEBHOOK_URLS" redact:"true"`
+	WebhookSecret string   `split_words:"true" redact:"true"`
+	WebhookEvents []string `split_words:"true"`
+
+	AuditLog           string `split_words:"true"`
+	AuditLogMaxSizeMB  int    `default:"100" split_words:"true"`
+	AuditLogMaxBackups int    `default:"5" split_words:"true"`
+
+	// Sources contains where settings were set, settings with default values are missing.
+	Sources        map[string]Source `ignored:"true" json:"-"`
 	CheckOrigin    func(string) bool `ignored:"true" json:"-"`
 	TurnExternal   bool              `ignored:"true"`
 	TurnIPProvider ipdns.Provider    `ignored:"true"`
 	TurnPort       string            `ignored:"true"`
+	TurnTLSPort    string            `ignored:"true"`
 
 	TurnDenyPeers       []string     `default:"0.0.0.0/8,127.0.0.1/8,::/128,::1/128,fe80::/10" split_words:"true"`
 	TurnDenyPeersParsed []*net.IPNet `ignored:"true"`
 
+	TurnAllowPeers       []string     `split_words:"true"`
+	TurnAllowPeersParsed []*net.IPNet `ignored:"true"`
+
+	TurnSessionPeersOnly bool `split_words:"true"`
+	TurnBindClientIP     bool `split_words:"true"`
+
 	CloseRoomWhenOwnerLeaves bool `default:"true" split_words:"true"`
+
+	ShutdownDrainPeriod time.Duration `default:"5s" split_words:"true"`
 }
 
 func (c Config) parsePortRange() (uint16, uint16, error) {
@@ -98,9 +172,31 @@ func (c Config) PortRange() (uint16, uint16, bool) {
 	return min, max, min != 0 && max != 0
 }
 
-// Get loads the application config.
-func Get() (Config, []FutureLog) {
+// Get loads the application config. configFile is an optional YAML file, environment variables take precedence
+// over it, it takes precedence over the env files.
+func Get(configFile string) (Config, []FutureLog) {
 	var logs []FutureLog
+	unsetLoadedKeys()
+	known := keys()
+	sources := map[string]Source{}
+	for _, key := range known {
+		if _, ok := os.LookupEnv(key); ok {
+			sources[key] = Source{}
+		}
+	}
+
+	if configFile != "" {
+		values, fileSources, fileLogs := readYAML(configFile, known)
+		logs = append(logs, fileLogs...)
+		for key, value := range values {
+			if _, ok := sources[key]; ok {
+				continue
+			}
+			setLoadedKey(key, value)
+			sources[key] = fileSources[key]
+		}
+	}
+
 	dir, log := getExecutableOrWorkDir()
 	if log != nil {
 		logs = append(logs, *log)
@@ -109,7 +205,7 @@ func Get() (Config, []FutureLog) {
 	for _, file := range getFiles(dir) {
 		_, fileErr := osStat(file)
 		if fileErr == nil {
-			if err := godotenv.Load(file); err != nil {
+			if err := loadEnvFile(file, known, sources); err != nil {
 				logs = append(logs, futureFatal(fmt.Sprintf("cannot load file %s: %s", file, err)))
 			} else {
 				logs = append(logs, FutureLog{
@@ -127,9 +223,13 @@ func Get() (Config, []FutureLog) {
 		}
 	}
 
-	config := Config{}
+	config := Config{Sources: sources}
 	err := envconfig.Process(prefix, &config)
-	if err != nil {
+	var parseErr *envconfig.ParseError
+	if errors.As(err, &parseErr) {
+		logs = append(logs,
+			futureFatal(fmt.Sprintf("invalid %s: %s", parseErr.KeyName, parseErr.Err)))
+	} else if err != nil {
 		logs = append(logs,
 			futureFatal(fmt.Sprintf("cannot parse env params: %s", err)))
 	}
@@ -139,7 +239,45 @@ func Get() (Config, []FutureLog) {
 			futureFatal(fmt.Sprintf("invalid SCREEGO_AUTH_MODE: %s", config.AuthMode)))
 	}
 
-	if config.ServerTLS {
+	if config.MetricsAddress != "" {
+		switch config.MetricsAuthMode {
+		case MetricsAuthNone, MetricsAuthUsers:
+		case MetricsAuthToken:
+			if config.MetricsToken == "" {
+				logs = append(logs, futureFatal("SCREEGO_METRICS_TOKEN must be set if SCREEGO_METRICS_AUTH_MODE is token"))
+			}
+		default:
+			logs = append(logs,
+				futureFatal(fmt.Sprintf("invalid SCREEGO_METRICS_AUTH_MODE: %s", config.MetricsAuthMode)))
+		}
+	}
+
+	if config.TracingEndpoint != "" {
+		if u, err := url.Parse(config.TracingEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TRACING_ENDPOINT: %s", config.TracingEndpoint)))
+		}
+		if config.TracingSampleRatio < 0 || config.TracingSampleRatio > 1 {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TRACING_SAMPLE_RATIO: %v must be between 0 and 1", config.TracingSampleRatio)))
+		}
+	}
+
+	if len(config.ACMEDomains) > 0 {
+		if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+			logs = append(logs, futureFatal("SCREEGO_ACME_DOMAINS and SCREEGO_TLS_CERT_FILE/SCREEGO_TLS_KEY_FILE must not be both set"))
+		}
+		if config.ACMECacheDir == "" {
+			logs = append(logs, futureFatal("SCREEGO_ACME_CACHE_DIR must be set if SCREEGO_ACME_DOMAINS is set"))
+		}
+		if config.ACMEDirectoryURL != "" {
+			if u, err := url.Parse(config.ACMEDirectoryURL); err != nil || u.Scheme != "https" {
+				logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_ACME_DIRECTORY_URL: %s", config.ACMEDirectoryURL)))
+			}
+		}
+	} else if config.ACMEHTTPAddress != "" {
+		logs = append(logs, futureFatal("SCREEGO_ACME_HTTP_ADDRESS requires SCREEGO_ACME_DOMAINS"))
+	}
+
+	if config.ServerTLS && len(config.ACMEDomains) == 0 {
 		if config.TLSCertFile == "" {
 			logs = append(logs, futureFatal("SCREEGO_TLS_CERT_FILE must be set if TLS is enabled"))
 		}
@@ -149,6 +287,20 @@ func Get() (Config, []FutureLog) {
 		}
 	}
 
+	tlsEnabled := len(config.ACMEDomains) > 0 || config.TLSCertFile != ""
+	if config.HTTP3Address != "" && !tlsEnabled {
+		logs = append(logs, futureFatal("SCREEGO_HTTP3_ADDRESS requires SCREEGO_ACME_DOMAINS or SCREEGO_TLS_CERT_FILE"))
+	}
+	if config.HTTP2Cleartext && tlsEnabled {
+		logs = append(logs, FutureLog{
+			Level: zerolog.WarnLevel,
+			Msg:   "SCREEGO_HTTP2_CLEARTEXT is ignored because TLS is enabled, HTTP/2 is used via TLS",
+		})
+	}
+
+	logs = append(logs, config.parseTLS()...)
+	logs = append(logs, config.parseUnixSocket()...)
+
 	var compiledAllowedOrigins []*regexp.Regexp
 	for _, origin := range config.CorsAllowedOrigins {
 		compiled, err := regexp.Compile(origin)
@@ -170,9 +322,28 @@ func Get() (Config, []FutureLog) {
 		return false
 	}
 
-	if len(config.Secret) == 0 {
-		config.Secret = make([]byte, 32)
-		if _, err := rand.Read(config.Secret); err == nil {
+	if secret, errs := secretFromFile(len(config.Secret) > 0, config.SecretFile, "SCREEGO_SECRET"); secret != "" {
+		config.Secret = []byte(secret)
+	} else {
+		logs = append(logs, errs...)
+	}
+	if secret, errs := secretFromFile(config.TurnExternalSecret != "", config.TurnExternalSecretFile, "SCREEGO_TURN_EXTERNAL_SECRET"); secret != "" {
+		config.TurnExternalSecret = secret
+	} else {
+		logs = append(logs, errs...)
+	}
+
+	if len(config.Secrets) > 0 {
+		if len(config.Secret) > 0 {
+			logs = append(logs, futureFatal("SCREEGO_SECRETS and SCREEGO_SECRET/SCREEGO_SECRET_FILE must not be both set"))
+		}
+		config.SessionSecrets = config.Secrets
+	} else if len(config.Secret) > 0 {
+		config.SessionSecrets = Secrets{config.Secret}
+	} else {
+		secret := make([]byte, 32)
+		config.SessionSecrets = Secrets{secret}
+		if _, err := rand.Read(secret); err == nil {
 			logs = append(logs, FutureLog{
 				Level: zerolog.InfoLevel,
 				Msg:   "SCREEGO_SECRET unset, user logins will be invalidated on restart",
@@ -199,12 +370,62 @@ func Get() (Config, []FutureLog) {
 	} else if len(config.ExternalIP) > 0 {
 		config.TurnIPProvider, errs = parseIPProvider(config.ExternalIP, "SCREEGO_EXTERNAL_IP")
 		logs = append(logs, errs...)
-		split := strings.Split(config.TurnAddress, ":")
-		config.TurnPort = split[len(split)-1]
+		if strings.HasPrefix(config.TurnAddress, systemd.Prefix) {
+			// the port of systemd sockets is set when the TURN server is started.
+			if len(config.TurnRelayIPs) > 0 {
+				logs = append(logs, futureFatal("SCREEGO_TURN_RELAY_IPS cannot be used with systemd socket activation"))
+			}
+		} else if _, port, err := net.SplitHostPort(config.TurnAddress); err == nil {
+			config.TurnPort = port
+		} else {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TURN_ADDRESS: %s", err)))
+		}
 	} else {
 		logs = append(logs, futureFatal("SCREEGO_EXTERNAL_IP or SCREEGO_TURN_EXTERNAL_IP must be set"))
 	}
 
+	if config.TurnTLSAddress != "" {
+		if config.TurnExternal {
+			logs = append(logs, futureFatal("SCREEGO_TURN_TLS_ADDRESS cannot be used with an external TURN server"))
+		}
+		if len(config.ACMEDomains) == 0 && config.TLSCertFile == "" {
+			logs = append(logs, futureFatal("SCREEGO_TURN_TLS_ADDRESS requires SCREEGO_ACME_DOMAINS or SCREEGO_TLS_CERT_FILE"))
+		}
+		if config.TurnTLSDomain == "" && len(config.ACMEDomains) > 0 {
+			config.TurnTLSDomain = config.ACMEDomains[0]
+		}
+		if config.TurnTLSDomain == "" {
+			logs = append(logs, futureFatal("SCREEGO_TURN_TLS_DOMAIN must be set if SCREEGO_TURN_TLS_ADDRESS is set"))
+		}
+		if strings.HasPrefix(config.TurnTLSAddress, systemd.Prefix) {
+			// the port of systemd sockets is set when the TURN server is started.
+			if len(config.TurnRelayIPs) > 0 {
+				logs = append(logs, futureFatal("SCREEGO_TURN_RELAY_IPS cannot be used with systemd socket activation"))
+			}
+		} else if _, port, err := net.SplitHostPort(config.TurnTLSAddress); err == nil {
+			config.TurnTLSPort = port
+		} else {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TURN_TLS_ADDRESS: %s", err)))
+		}
+	}
+
+	for _, webhookURL := range config.WebhookURLs {
+		if u, err := url.Parse(webhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_WEBHOOK_URLS: %s", webhookURL)))
+		}
+	}
+	for _, event := range config.WebhookEvents {
+		if !slices.Contains(webhook.Events, webhook.Event(event)) {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_WEBHOOK_EVENTS: unknown event %s", event)))
+		}
+	}
+	if len(config.WebhookURLs) > 0 && config.WebhookSecret == "" {
+		logs = append(logs, FutureLog{
+			Level: zerolog.WarnLevel,
+			Msg:   "SCREEGO_WEBHOOK_SECRET is unset, webhook payloads will not be signed",
+		})
+	}
+
 	min, max, err := config.parsePortRange()
 	if err != nil {
 		logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TURN_PORT_RANGE: %s", err)))
@@ -222,23 +443,55 @@ func Get() (Config, []FutureLog) {
 	}
 	logs = append(logs, logDeprecated()...)
 
-	for _, cidrString := range config.TurnDenyPeers {
+	if config.TurnCredentialTTL < time.Minute {
+		logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TURN_CREDENTIAL_TTL: %s must be at least one minute", config.TurnCredentialTTL)))
+	}
+
+	config.TurnDenyPeersParsed, errs = parseCIDRs(config.TurnDenyPeers, "SCREEGO_TURN_DENY_PEERS")
+	logs = append(logs, errs...)
+	logs = append(logs, FutureLog{
+		Level: zerolog.InfoLevel,
+		Msg:   fmt.Sprintf("Deny turn peers within %q", config.TurnDenyPeersParsed),
+	})
+
+	config.TurnAllowPeersParsed, errs = parseCIDRs(config.TurnAllowPeers, "SCREEGO_TURN_ALLOW_PEERS")
+	logs = append(logs, errs...)
+	if len(config.TurnAllowPeersParsed) > 0 {
+		logs = append(logs, FutureLog{
+			Level: zerolog.InfoLevel,
+			Msg:   fmt.Sprintf("Only allow turn peers within %q", config.TurnAllowPeersParsed),
+		})
+	}
+
+	config.TurnRelayIPsParsed, errs = parseRelayIPs(config.TurnRelayIPs)
+	logs = append(logs, errs...)
+
+	if config.TurnExternal && (len(config.TurnRelayIPs) > 0 || len(config.TurnAllowPeers) > 0 || config.TurnSessionPeersOnly || config.TurnBindClientIP) {
+		logs = append(logs, FutureLog{
+			Level: zerolog.WarnLevel,
+			Msg:   "SCREEGO_TURN_RELAY_IPS, SCREEGO_TURN_ALLOW_PEERS, SCREEGO_TURN_SESSION_PEERS_ONLY and SCREEGO_TURN_BIND_CLIENT_IP have no effect when an external TURN server is used",
+		})
+	}
+
+	annotateSources(logs, sources)
+	return config, logs
+}
+
+func parseCIDRs(values []string, name string) ([]*net.IPNet, []FutureLog) {
+	var logs []FutureLog
+	var result []*net.IPNet
+	for _, cidrString := range values {
 		_, cidr, err := net.ParseCIDR(cidrString)
 		if err != nil {
 			logs = append(logs, FutureLog{
 				Level: zerolog.FatalLevel,
-				Msg:   fmt.Sprintf("Invalid SCREEGO_TURN_DENY_PEERS %q: %s", cidrString, err),
+				Msg:   fmt.Sprintf("Invalid %s %q: %s", name, cidrString, err),
 			})
 		} else {
-			config.TurnDenyPeersParsed = append(config.TurnDenyPeersParsed, cidr)
+			result = append(result, cidr)
 		}
 	}
-	logs = append(logs, FutureLog{
-		Level: zerolog.InfoLevel,
-		Msg:   fmt.Sprintf("Deny turn peers within %q", config.TurnDenyPeersParsed),
-	})
-
-	return config, logs
+	return result, logs
 }
 
 func logDeprecated() []FutureLog {
@@ -269,6 +522,24 @@ func getExecutableDir() (string, *FutureLog) {
 	return filepath.Dir(ex), nil
 }
 
+// loadEnvFile sets the environment variables of an env file which are not set yet, like godotenv.Load.
+func loadEnvFile(file string, known []string, sources map[string]Source) error {
+	values, err := godotenv.Read(file)
+	if err != nil {
+		return err
+	}
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		setLoadedKey(key, value)
+		if slices.Contains(known, key) {
+			sources[key] = Source{File: file}
+		}
+	}
+	return nil
+}
+
 func getFiles(relativeTo string) []string {
 	var result []string
 	for _, file := range files {
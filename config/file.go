@@ -0,0 +1,158 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// Source describes where a setting was set.
+type Source struct {
+	// File is empty for environment variables.
+	File string
+	// Line is zero if unknown.
+	Line int
+}
+
+func (s Source) String() string {
+	if s.File == "" {
+		return "environment"
+	}
+	if s.Line == 0 {
+		return s.File
+	}
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// Setting describes a config setting.
+type Setting struct {
+	// Key is the environment variable name, f.ex. SCREEGO_TURN_DENY_PEERS.
+	Key     string
+	Default string
+	// Redact is set for secrets which must not be printed.
+	Redact bool
+}
+
+// Settings returns all config settings.
+func Settings() []Setting {
+	var buf bytes.Buffer
+	format := "{{range .}}{{.Key}}\t{{.Tags.Get \"default\"}}\t{{.Tags.Get \"redact\"}}\n{{end}}"
+	if err := envconfig.Usagef(prefix, &Config{}, &buf, format); err != nil {
+		panic(err)
+	}
+	var result []Setting
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		parts := strings.Split(line, "\t")
+		result = append(result, Setting{Key: parts[0], Default: parts[1], Redact: parts[2] == "true"})
+	}
+	return result
+}
+
+// keys returns the environment variable names of all settings.
+func keys() []string {
+	var result []string
+	for _, setting := range Settings() {
+		result = append(result, setting.Key)
+	}
+	return result
+}
+
+// readYAML reads a YAML config file. The setting names are the environment variable names in lower case without
+// prefix, f.ex. turn_deny_peers for SCREEGO_TURN_DENY_PEERS. Lists are converted to comma separated values.
+func readYAML(path string, known []string) (map[string]string, map[string]Source, []FutureLog) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, []FutureLog{futureFatal(fmt.Sprintf("cannot read config file %s: %s", path, err))}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, []FutureLog{futureFatal(fmt.Sprintf("cannot parse config file %s: %s", path, err))}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, []FutureLog{futureFatal(fmt.Sprintf("%s: config file must contain a mapping of settings", Source{File: path, Line: root.Line}))}
+	}
+
+	values := map[string]string{}
+	sources := map[string]Source{}
+	var logs []FutureLog
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		keyNode, valueNode := root.Content[i], root.Content[i+1]
+		source := Source{File: path, Line: keyNode.Line}
+
+		key := strings.ToUpper(prefix + "_" + keyNode.Value)
+		if !slices.Contains(known, key) {
+			logs = append(logs, futureFatal(fmt.Sprintf("%s: unknown setting %s", source, keyNode.Value)))
+			continue
+		}
+		if _, ok := values[key]; ok {
+			logs = append(logs, futureFatal(fmt.Sprintf("%s: duplicate setting %s", source, keyNode.Value)))
+			continue
+		}
+
+		value, line, err := yamlValue(valueNode)
+		if err != nil {
+			logs = append(logs, futureFatal(fmt.Sprintf("%s: invalid value for %s: %s", Source{File: path, Line: line}, keyNode.Value, err)))
+			continue
+		}
+		values[key] = value
+		sources[key] = source
+	}
+	return values, sources, logs
+}
+
+// yamlValue converts the node to the environment variable format, on errors the line of the invalid node is returned.
+func yamlValue(node *yaml.Node) (string, int, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "", 0, nil
+		}
+		return node.Value, 0, nil
+	case yaml.SequenceNode:
+		var items []string
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return "", item.Line, errors.New("list items must be plain values")
+			}
+			if strings.Contains(item.Value, ",") {
+				return "", item.Line, errors.New("list items must not contain a comma")
+			}
+			items = append(items, item.Value)
+		}
+		return strings.Join(items, ","), 0, nil
+	default:
+		return "", node.Line, errors.New("must be a value or a list of values")
+	}
+}
+
+var keyPattern = regexp.MustCompile(strings.ToUpper(prefix) + `_[A-Z0-9_]+`)
+
+// annotateSources adds the source of the first mentioned setting to fatal messages, so that invalid values can be
+// found in the config files.
+func annotateSources(logs []FutureLog, sources map[string]Source) {
+	for i, log := range logs {
+		if log.Level != zerolog.FatalLevel {
+			continue
+		}
+		for _, key := range keyPattern.FindAllString(log.Msg, -1) {
+			if source, ok := sources[key]; ok {
+				logs[i].Msg = fmt.Sprintf("%s (set in %s)", log.Msg, source)
+				break
+			}
+		}
+	}
+}
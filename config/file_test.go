@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	known := keys()
+	assert.Contains(t, known, "SCREEGO_TURN_DENY_PEERS")
+	assert.Contains(t, known, "SCREEGO_TLS_CERT_FILE")
+	assert.Contains(t, known, "SCREEGO_WEBHOOK_URLS")
+	assert.NotContains(t, known, "SCREEGO_TURN_IP_PROVIDER")
+}
+
+func TestReadYAML(t *testing.T) {
+	file := writeFile(t, `
+server_address: 127.0.0.1:5050
+prometheus: true
+secret:
+turn_deny_peers:
+  - 10.0.0.0/8
+  - fe80::/10
+`)
+
+	values, sources, logs := readYAML(file, keys())
+	assert.Empty(t, logs)
+	assert.Equal(t, map[string]string{
+		"SCREEGO_SERVER_ADDRESS":  "127.0.0.1:5050",
+		"SCREEGO_PROMETHEUS":      "true",
+		"SCREEGO_SECRET":          "",
+		"SCREEGO_TURN_DENY_PEERS": "10.0.0.0/8,fe80::/10",
+	}, values)
+	assert.Equal(t, Source{File: file, Line: 5}, sources["SCREEGO_TURN_DENY_PEERS"])
+}
+
+func TestReadYAML_invalid(t *testing.T) {
+	file := writeFile(t, `
+server_address: 127.0.0.1:5050
+unknown: true
+cors_allowed_origins:
+  - a: b
+`)
+
+	values, _, logs := readYAML(file, keys())
+	assert.Equal(t, map[string]string{"SCREEGO_SERVER_ADDRESS": "127.0.0.1:5050"}, values)
+	require.Len(t, logs, 2)
+	assert.Equal(t, file+":3: unknown setting unknown", logs[0].Msg)
+	assert.Equal(t, file+":5: invalid value for cors_allowed_origins: list items must be plain values", logs[1].Msg)
+}
+
+func TestAnnotateSources(t *testing.T) {
+	logs := []FutureLog{futureFatal("invalid SCREEGO_AUTH_MODE: abc"), futureFatal("invalid SCREEGO_SECRET")}
+	annotateSources(logs, map[string]Source{"SCREEGO_AUTH_MODE": {File: "screego.yml", Line: 3}})
+	assert.Equal(t, "invalid SCREEGO_AUTH_MODE: abc (set in screego.yml:3)", logs[0].Msg)
+	assert.Equal(t, "invalid SCREEGO_SECRET", logs[1].Msg)
+}
+
+func writeFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "screego.yml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+	return file
+}
@@ -4,12 +4,39 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/screego/server/config/ipdns"
 )
 
+// RelayIP maps a local ip the TURN server listens and relays on to the ip that is advertised to clients.
+type RelayIP struct {
+	Relay    net.IP
+	External net.IP
+}
+
+func parseRelayIPs(values []string) ([]RelayIP, []FutureLog) {
+	var result []RelayIP
+	for _, value := range values {
+		relayString, externalString, mapped := strings.Cut(value, "/")
+		relay := net.ParseIP(relayString)
+		external := relay
+		if mapped {
+			external = net.ParseIP(externalString)
+		}
+		if relay == nil || external == nil || relay.IsUnspecified() || external.IsUnspecified() {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid SCREEGO_TURN_RELAY_IPS: %s", value))}
+		}
+		if (relay.To4() == nil) != (external.To4() == nil) {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid SCREEGO_TURN_RELAY_IPS: %s the ips must be of the same type ipv4/ipv6", value))}
+		}
+		result = append(result, RelayIP{Relay: relay, External: external})
+	}
+	return result, nil
+}
+
 func parseIPProvider(ips []string, config string) (ipdns.Provider, []FutureLog) {
 	if len(ips) == 0 {
 		panic("must have at least one ip")
@@ -24,6 +51,29 @@ func parseIPProvider(ips []string, config string) (ipdns.Provider, []FutureLog)
 		return parseDNS(strings.TrimPrefix(first, "dns:")), nil
 	}
 
+	if strings.HasPrefix(first, "http:") {
+		if len(ips) > 1 {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid %s: when an http url is specified, only one value is allowed, use http4: and http6: for separate urls", config))}
+		}
+		return parseHTTP(ips, config)
+	}
+
+	if strings.HasPrefix(first, "http4:") || strings.HasPrefix(first, "http6:") {
+		return parseHTTP(ips, config)
+	}
+
+	if strings.HasPrefix(first, "stun:") {
+		return parseSTUN(ips, config)
+	}
+
+	if strings.HasPrefix(first, "iface:") {
+		if len(ips) > 1 {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid %s: when an interface is specified, only one value is allowed", config))}
+		}
+
+		return &ipdns.Interface{Name: strings.TrimPrefix(first, "iface:")}, nil
+	}
+
 	return parseStatic(ips, config)
 }
 
@@ -70,6 +120,47 @@ func applyIPTo(config, ip string, static *ipdns.Static) (bool, []FutureLog) {
 	return v4, nil
 }
 
+func parseHTTP(values []string, config string) (*ipdns.HTTP, []FutureLog) {
+	provider := &ipdns.HTTP{}
+	for _, value := range values {
+		var target *string
+		var rawURL string
+		if anyURL, ok := strings.CutPrefix(value, "http:"); ok && len(values) == 1 {
+			target, rawURL = &provider.URL, anyURL
+		} else if v4URL, ok := strings.CutPrefix(value, "http4:"); ok {
+			target, rawURL = &provider.V4URL, v4URL
+		} else if v6URL, ok := strings.CutPrefix(value, "http6:"); ok {
+			target, rawURL = &provider.V6URL, v6URL
+		} else {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid %s: when an http url is specified, all values must be prefixed with http4: or http6:", config))}
+		}
+		if *target != "" {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid %s: only one url per ip family is allowed", config))}
+		}
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid %s: %s is not a valid http url", config, rawURL))}
+		}
+		*target = rawURL
+	}
+	return provider, nil
+}
+
+func parseSTUN(values []string, config string) (*ipdns.STUN, []FutureLog) {
+	provider := &ipdns.STUN{}
+	for _, value := range values {
+		if !strings.HasPrefix(value, "stun:") {
+			return nil, []FutureLog{futureFatal(fmt.Sprintf("invalid %s: when a stun server is specified, all values must be prefixed with stun:", config))}
+		}
+		server := strings.TrimPrefix(value, "stun:")
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), "3478")
+		}
+		provider.Servers = append(provider.Servers, server)
+	}
+	return provider, nil
+}
+
 func parseDNS(dnsString string) *ipdns.DNS {
 	var dns ipdns.DNS
 
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"net"
+	"testing"
+
+	"github.com/screego/server/config/ipdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRelayIPs(t *testing.T) {
+	relays, errs := parseRelayIPs([]string{"10.0.0.5/203.0.113.5", "2001:db8::5"})
+	assert.Empty(t, errs)
+	assert.Equal(t, []RelayIP{
+		{Relay: net.ParseIP("10.0.0.5"), External: net.ParseIP("203.0.113.5")},
+		{Relay: net.ParseIP("2001:db8::5"), External: net.ParseIP("2001:db8::5")},
+	}, relays)
+}
+
+func TestParseRelayIPs_invalid(t *testing.T) {
+	for _, value := range []string{"invalid", "0.0.0.0", "10.0.0.5/", "10.0.0.5/2001:db8::5"} {
+		_, errs := parseRelayIPs([]string{value})
+		assert.Len(t, errs, 1, value)
+	}
+}
+
+func TestParseHTTP(t *testing.T) {
+	provider, errs := parseHTTP([]string{"http4:https://api.ipify.org", "http6:https://api6.ipify.org"}, "SCREEGO_EXTERNAL_IP")
+	assert.Empty(t, errs)
+	assert.Equal(t, "https://api.ipify.org", provider.V4URL)
+	assert.Equal(t, "https://api6.ipify.org", provider.V6URL)
+}
+
+func TestParseHTTP_anyFamily(t *testing.T) {
+	provider, errs := parseIPProvider([]string{"http:https://api.ipify.org"}, "SCREEGO_EXTERNAL_IP")
+	assert.Empty(t, errs)
+	assert.Equal(t, &ipdns.HTTP{URL: "https://api.ipify.org"}, provider)
+}
+
+func TestParseHTTP_invalid(t *testing.T) {
+	for _, values := range [][]string{
+		{"http:https://api.ipify.org", "http6:https://api6.ipify.org"},
+		{"http4:https://api.ipify.org", "http:https://api.ipify.org"},
+		{"http4:https://api.ipify.org", "http4:https://api.ipify.org"},
+		{"http6:ftp://api6.ipify.org"},
+	} {
+		_, errs := parseHTTP(values, "SCREEGO_EXTERNAL_IP")
+		assert.Len(t, errs, 1, values)
+	}
+}
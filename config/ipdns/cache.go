@@ -0,0 +1,93 @@
+package ipdns
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// cache stores the result of an external ip lookup. Successful lookups are refetched after the interval,
+// failed lookups are retried after a second. Only the first lookup blocks, later lookups run in the background
+// while the last result is returned. Listeners are notified when a lookup returns a different ip than the
+// last successful one.
+type cache struct {
+	sync.Mutex
+
+	initialized bool
+	refreshing  bool
+	refetch     time.Time
+	fetched     bool
+	v4          net.IP
+	v6          net.IP
+	err         error
+	listeners   []func(v4, v6 net.IP)
+}
+
+func (c *cache) get(interval time.Duration, msg string, fields func(*zerolog.Event) *zerolog.Event, lookup func() (net.IP, net.IP, error)) (net.IP, net.IP, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.initialized {
+		// there is no ip to return yet, concurrent callers wait for the lookup.
+		c.initialized = true
+		v4, v6, err := lookup()
+		c.store(interval, msg, fields, v4, v6, err)
+	} else if !c.refreshing && c.refetch.Before(time.Now()) {
+		c.refreshing = true
+		go c.refresh(interval, msg, fields, lookup)
+	}
+
+	if c.err != nil {
+		return nil, nil, c.err
+	}
+	return c.v4, c.v6, nil
+}
+
+// refresh looks up the ip without holding the lock, callers of get aren't blocked by slow lookups.
+func (c *cache) refresh(interval time.Duration, msg string, fields func(*zerolog.Event) *zerolog.Event, lookup func() (net.IP, net.IP, error)) {
+	v4, v6, err := lookup()
+
+	c.Lock()
+	changed := c.store(interval, msg, fields, v4, v6, err)
+	c.refreshing = false
+	listeners := c.listeners
+	c.Unlock()
+
+	if changed {
+		externalIPChangesTotal.Inc()
+		for _, listener := range listeners {
+			listener(v4, v6)
+		}
+	}
+}
+
+// store saves the lookup result and returns true if the ip changed. The lock must be held.
+func (c *cache) store(interval time.Duration, msg string, fields func(*zerolog.Event) *zerolog.Event, v4, v6 net.IP, err error) bool {
+	c.err = err
+	if err != nil {
+		// don't spam the server
+		c.refetch = time.Now().Add(time.Second)
+		fields(log.Err(err)).Msg(msg)
+		return false
+	}
+
+	changed := false
+	if !c.v4.Equal(v4) || !c.v6.Equal(v6) {
+		fields(log.Info().Str("v4", v4.String()).Str("v6", v6.String())).Msg(msg)
+		// the first lookup isn't a change
+		changed = c.fetched
+	}
+	c.v4, c.v6 = v4, v6
+	c.fetched = true
+	c.refetch = time.Now().Add(interval)
+	return changed
+}
+
+func (c *cache) onChange(listener func(v4, v6 net.IP)) {
+	c.Lock()
+	defer c.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
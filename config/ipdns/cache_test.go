@@ -0,0 +1,71 @@
+package ipdns
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+var noFields = func(e *zerolog.Event) *zerolog.Event { return e }
+
+func lookupIP(v4 string, err error) func() (net.IP, net.IP, error) {
+	return func() (net.IP, net.IP, error) {
+		return net.ParseIP(v4), nil, err
+	}
+}
+
+func TestCache_store(t *testing.T) {
+	c := cache{}
+
+	assert.False(t, c.store(0, "test", noFields, net.ParseIP("203.0.113.1"), nil, nil), "the first lookup isn't a change")
+	assert.False(t, c.store(0, "test", noFields, net.ParseIP("203.0.113.1"), nil, nil))
+	assert.False(t, c.store(0, "test", noFields, nil, nil, errors.New("failed")))
+	assert.False(t, c.store(0, "test", noFields, net.ParseIP("203.0.113.1"), nil, nil), "recovering with the same ip isn't a change")
+	assert.True(t, c.store(0, "test", noFields, net.ParseIP("203.0.113.2"), nil, nil))
+}
+
+func TestCache_refreshesInBackground(t *testing.T) {
+	c := cache{}
+	changes := make(chan net.IP, 1)
+	c.onChange(func(v4, v6 net.IP) {
+		changes <- v4
+	})
+
+	v4, _, err := c.get(0, "test", noFields, lookupIP("203.0.113.1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, net.ParseIP("203.0.113.1"), v4)
+
+	release := make(chan struct{})
+	slowLookup := func() (net.IP, net.IP, error) {
+		<-release
+		return net.ParseIP("203.0.113.2"), nil, nil
+	}
+	start := time.Now()
+	v4, _, err = c.get(0, "test", noFields, slowLookup)
+	assert.NoError(t, err)
+	assert.Equal(t, net.ParseIP("203.0.113.1"), v4, "the cached ip is returned while refreshing")
+	v4, _, _ = c.get(0, "test", noFields, slowLookup)
+	assert.Equal(t, net.ParseIP("203.0.113.1"), v4)
+	assert.Less(t, time.Since(start), time.Second)
+	close(release)
+
+	select {
+	case changed := <-changes:
+		assert.Equal(t, net.ParseIP("203.0.113.2"), changed)
+	case <-time.After(time.Second):
+		t.Fatal("listener wasn't notified")
+	}
+	v4, _, _ = c.get(time.Minute, "test", noFields, lookupIP("203.0.113.3", nil))
+	assert.Equal(t, net.ParseIP("203.0.113.2"), v4)
+}
+
+func TestCache_failedFirstLookup(t *testing.T) {
+	c := cache{}
+	v4, _, err := c.get(0, "test", noFields, lookupIP("", errors.New("failed")))
+	assert.Error(t, err)
+	assert.Nil(t, v4)
+}
@@ -5,49 +5,27 @@ import (
 	"errors"
 	"net"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 )
 
 type DNS struct {
-	sync.Mutex
-
 	DNS      string
 	Resolver *net.Resolver
 	Domain   string
 
-	refetch time.Time
-	v4      net.IP
-	v6      net.IP
-	err     error
+	cache cache
 }
 
 func (s *DNS) Get() (net.IP, net.IP, error) {
-	s.Lock()
-	defer s.Unlock()
-
-	if s.refetch.Before(time.Now()) {
-		oldV4, oldV6 := s.v4, s.v6
-		s.v4, s.v6, s.err = s.lookup()
-		if s.err == nil {
-			if !oldV4.Equal(s.v4) || !oldV6.Equal(s.v6) {
-				log.Info().Str("v4", s.v4.String()).
-					Str("v6", s.v6.String()).
-					Str("domain", s.Domain).
-					Str("dns", s.DNS).
-					Msg("DNS External IP")
-			}
-			s.refetch = time.Now().Add(time.Minute)
-		} else {
-			// don't spam the dns server
-			s.refetch = time.Now().Add(time.Second)
-			log.Err(s.err).Str("domain", s.Domain).Str("dns", s.DNS).Msg("DNS External IP")
-		}
-	}
+	return s.cache.get(time.Minute, "DNS External IP", func(e *zerolog.Event) *zerolog.Event {
+		return e.Str("domain", s.Domain).Str("dns", s.DNS)
+	}, s.lookup)
+}
 
-	return s.v4, s.v6, s.err
+func (s *DNS) OnChange(listener func(v4, v6 net.IP)) {
+	s.cache.onChange(listener)
 }
 
 func (s *DNS) lookup() (net.IP, net.IP, error) {
@@ -0,0 +1,119 @@
+package ipdns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// HTTP obtains the external ip from web services which respond with the ip of the caller in plain text,
+// f.ex. https://api.ipify.org or the metadata service of a cloud provider. URL is requested via any ip family and the
+// response is used as v4 or v6 ip depending on its family. V4URL is requested via IPv4 and V6URL via IPv6. Each may be
+// empty.
+type HTTP struct {
+	URL   string
+	V4URL string
+	V6URL string
+
+	cache cache
+}
+
+var (
+	client   = familyClient("tcp")
+	v4Client = familyClient("tcp4")
+	v6Client = familyClient("tcp6")
+)
+
+// familyClient returns a client which only connects via the network tcp, tcp4 or tcp6. Otherwise, the ip of the other
+// family may be returned on dual stack hosts.
+func familyClient(network string) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Timeout: 10 * time.Second, Transport: transport}
+}
+
+func (s *HTTP) Get() (net.IP, net.IP, error) {
+	return s.cache.get(time.Minute, "HTTP External IP", func(e *zerolog.Event) *zerolog.Event {
+		return e.Str("url", s.URL).Str("v4url", s.V4URL).Str("v6url", s.V6URL)
+	}, s.lookup)
+}
+
+func (s *HTTP) OnChange(listener func(v4, v6 net.IP)) {
+	s.cache.onChange(listener)
+}
+
+func (s *HTTP) lookup() (net.IP, net.IP, error) {
+	var v4, v6 net.IP
+	if s.URL != "" {
+		ip, err := fetch(client, s.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ip.To4() != nil {
+			v4 = ip
+		} else {
+			v6 = ip
+		}
+	}
+	if s.V4URL != "" {
+		ip, err := fetch(v4Client, s.V4URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ip.To4() == nil {
+			return nil, nil, fmt.Errorf("%s responded with %s instead of an IPv4 address", s.V4URL, ip)
+		}
+		v4 = ip
+	}
+	if s.V6URL != "" {
+		ip, err := fetch(v6Client, s.V6URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ip.To4() != nil {
+			return nil, nil, fmt.Errorf("%s responded with %s instead of an IPv6 address", s.V6URL, ip)
+		}
+		v6 = ip
+	}
+	return v4, v6, nil
+}
+
+func fetch(client *http.Client, url string) (net.IP, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return nil, fmt.Errorf("%s responded with an invalid ip %q", url, body)
+	}
+	return ip, nil
+}
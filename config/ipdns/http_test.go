@@ -0,0 +1,66 @@
+package ipdns
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ipServer(t *testing.T, network, address, body string) *httptest.Server {
+	listener, err := net.Listen(network, address)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintln(w, body)
+	}))
+	_ = server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTP_Get(t *testing.T) {
+	v4Server := ipServer(t, "tcp4", "127.0.0.1:0", "203.0.113.5")
+	v6Server := ipServer(t, "tcp6", "[::1]:0", "2001:db8::5")
+
+	provider := &HTTP{V4URL: v4Server.URL, V6URL: v6Server.URL}
+	v4, v6, err := provider.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, net.ParseIP("203.0.113.5"), v4)
+	assert.Equal(t, net.ParseIP("2001:db8::5"), v6)
+}
+
+func TestHTTP_Get_anyFamily(t *testing.T) {
+	server := ipServer(t, "tcp4", "127.0.0.1:0", "2001:db8::5")
+
+	v4, v6, err := (&HTTP{URL: server.URL}).Get()
+	assert.NoError(t, err)
+	assert.Nil(t, v4)
+	assert.Equal(t, net.ParseIP("2001:db8::5"), v6)
+}
+
+func TestHTTP_Get_invalidResponse(t *testing.T) {
+	server := ipServer(t, "tcp4", "127.0.0.1:0", "<html>")
+
+	_, _, err := (&HTTP{V4URL: server.URL}).Get()
+	assert.ErrorContains(t, err, "invalid ip")
+}
+
+func TestHTTP_Get_wrongFamily(t *testing.T) {
+	server := ipServer(t, "tcp4", "127.0.0.1:0", "2001:db8::5")
+
+	_, _, err := (&HTTP{V4URL: server.URL}).Get()
+	assert.EqualError(t, err, server.URL+" responded with 2001:db8::5 instead of an IPv4 address")
+}
+
+func TestHTTP_Get_forcesFamily(t *testing.T) {
+	server := ipServer(t, "tcp4", "127.0.0.1:0", "203.0.113.5")
+
+	_, _, err := (&HTTP{V6URL: server.URL}).Get()
+	assert.ErrorContains(t, err, "dial tcp6", "the v6 url is only requested via IPv6")
+}
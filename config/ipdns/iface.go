@@ -0,0 +1,64 @@
+package ipdns
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Interface obtains the external ip from the global unicast addresses of a network interface.
+// The addresses are re-read frequently to follow changes f.ex. of dynamic IPv6 prefixes.
+type Interface struct {
+	Name string
+
+	cache cache
+}
+
+func (s *Interface) Get() (net.IP, net.IP, error) {
+	return s.cache.get(10*time.Second, "Interface External IP", func(e *zerolog.Event) *zerolog.Event {
+		return e.Str("interface", s.Name)
+	}, s.lookup)
+}
+
+func (s *Interface) OnChange(listener func(v4, v6 net.IP)) {
+	s.cache.onChange(listener)
+}
+
+func (s *Interface) lookup() (net.IP, net.IP, error) {
+	iface, err := net.InterfaceByName(s.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, nil, err
+	}
+	return globalUnicast(addrs)
+}
+
+// globalUnicast returns the first global IPv4 and IPv6 address, link-local and unique local (fc00::/7)
+// addresses are skipped.
+func globalUnicast(addrs []net.Addr) (net.IP, net.IP, error) {
+	var v4, v6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+
+		if ip := ipNet.IP.To4(); ip != nil {
+			if v4 == nil {
+				v4 = ip
+			}
+		} else if v6 == nil && !ipNet.IP.IsPrivate() {
+			v6 = ipNet.IP
+		}
+	}
+
+	if v4 == nil && v6 == nil {
+		return nil, nil, errors.New("interface doesn't have a global unicast address")
+	}
+	return v4, v6, nil
+}
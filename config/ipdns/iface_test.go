@@ -0,0 +1,43 @@
+package ipdns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ipNet(s string) net.Addr {
+	ip, network, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	network.IP = ip
+	return network
+}
+
+func TestGlobalUnicast(t *testing.T) {
+	v4, v6, err := globalUnicast([]net.Addr{
+		ipNet("127.0.0.1/8"),
+		ipNet("169.254.10.1/16"),
+		ipNet("203.0.113.5/24"),
+		ipNet("::1/128"),
+		ipNet("fe80::1/64"),
+		ipNet("fd00::1/64"),
+		ipNet("2001:db8::5/64"),
+		ipNet("2001:db8::6/64"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.5", v4.String())
+	assert.Equal(t, "2001:db8::5", v6.String())
+}
+
+func TestGlobalUnicast_none(t *testing.T) {
+	_, _, err := globalUnicast([]net.Addr{ipNet("127.0.0.1/8"), ipNet("fe80::1/64")})
+	assert.Error(t, err)
+}
+
+func TestInterface_Get_unknown(t *testing.T) {
+	_, _, err := (&Interface{Name: "screego-does-not-exist"}).Get()
+	assert.Error(t, err)
+}
@@ -0,0 +1,11 @@
+package ipdns
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var externalIPChangesTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "screego_external_ip_changes_total",
+	Help: "The total number of external ip changes",
+})
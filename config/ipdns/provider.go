@@ -4,4 +4,7 @@ import "net"
 
 type Provider interface {
 	Get() (net.IP, net.IP, error)
+	// OnChange registers a listener that is called when Get detects a change of the external ip.
+	// The listener must not block.
+	OnChange(listener func(v4, v6 net.IP))
 }
@@ -10,3 +10,7 @@ type Static struct {
 func (s *Static) Get() (net.IP, net.IP, error) {
 	return s.V4, s.V6, nil
 }
+
+func (s *Static) OnChange(listener func(v4, v6 net.IP)) {
+	// static ips never change
+}
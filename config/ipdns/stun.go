@@ -0,0 +1,121 @@
+package ipdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/stun/v3"
+	"github.com/rs/zerolog"
+)
+
+// STUN obtains the external ip by sending binding requests to STUN servers. Each server is queried via IPv4
+// and IPv6 in parallel until an address for both families was found. A family is no longer queried when a server
+// only answered via the other family.
+type STUN struct {
+	Servers []string
+	Timeout time.Duration
+
+	cache cache
+}
+
+func (s *STUN) Get() (net.IP, net.IP, error) {
+	return s.cache.get(time.Minute, "STUN External IP", func(e *zerolog.Event) *zerolog.Event {
+		return e.Strs("servers", s.Servers)
+	}, s.lookup)
+}
+
+func (s *STUN) OnChange(listener func(v4, v6 net.IP)) {
+	s.cache.onChange(listener)
+}
+
+func (s *STUN) lookup() (net.IP, net.IP, error) {
+	networks := []string{"udp4", "udp6"}
+	found := make([]net.IP, len(networks))
+	// skip contains the families which aren't available on this host, they aren't queried on the other servers.
+	skip := make([]bool, len(networks))
+	var errs []error
+	for _, server := range s.Servers {
+		results := make([]error, len(networks))
+		var wg sync.WaitGroup
+		for i, network := range networks {
+			if found[i] != nil || skip[i] {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				found[i], results[i] = s.query(network, server)
+			}()
+		}
+		wg.Wait()
+
+		answered := false
+		for i, err := range results {
+			if err != nil {
+				errs = append(errs, err)
+			} else if found[i] != nil {
+				answered = true
+			}
+		}
+		done := true
+		for i, err := range results {
+			// the server answered via the other family, so this family doesn't work on this host.
+			skip[i] = skip[i] || (err != nil && answered)
+			done = done && (found[i] != nil || skip[i])
+		}
+		if done {
+			break
+		}
+	}
+
+	if found[0] == nil && found[1] == nil {
+		return nil, nil, fmt.Errorf("no stun server responded with a mapped address: %w", errors.Join(errs...))
+	}
+	return found[0], found[1], nil
+}
+
+func (s *STUN) query(network, server string) (net.IP, error) {
+	conn, err := net.Dial(network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 3 * time.Second
+	}
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	request := stun.MustBuild(stun.TransactionID, stun.BindingRequest)
+	if _, err := conn.Write(request.Raw); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &stun.Message{Raw: buf[:n]}
+	if err := response.Decode(); err != nil {
+		return nil, fmt.Errorf("%s: %w", server, err)
+	}
+	if response.TransactionID != request.TransactionID {
+		return nil, fmt.Errorf("%s: transaction id mismatch", server)
+	}
+
+	var xorAddr stun.XORMappedAddress
+	if err := xorAddr.GetFrom(response); err == nil {
+		return xorAddr.IP, nil
+	}
+	var addr stun.MappedAddress
+	if err := addr.GetFrom(response); err != nil {
+		return nil, fmt.Errorf("%s: %w", server, err)
+	}
+	return addr.IP, nil
+}
@@ -0,0 +1,44 @@
+package ipdns
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pion/turn/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSTUN_Get(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server, err := turn.NewServer(turn.ServerConfig{
+		PacketConnConfigs: []turn.PacketConnConfig{{
+			PacketConn: conn,
+			RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+				RelayAddress: net.ParseIP("127.0.0.1"),
+				Address:      "127.0.0.1",
+			},
+		}},
+	})
+	require.NoError(t, err)
+	defer server.Close()
+
+	provider := &STUN{Servers: []string{conn.LocalAddr().String()}}
+	v4, v6, err := provider.Get()
+	assert.NoError(t, err)
+	assert.True(t, net.ParseIP("127.0.0.1").Equal(v4))
+	assert.Nil(t, v6)
+}
+
+func TestSTUN_Get_noServerResponds(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	provider := &STUN{Servers: []string{conn.LocalAddr().String()}, Timeout: 50 * time.Millisecond}
+	_, _, err = provider.Get()
+	assert.ErrorContains(t, err, "no stun server responded")
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+const redacted = "<redacted>"
+
+// Print writes the effective settings in the env file format with the source of each value as comment.
+// Secrets are redacted. It must be called after Get.
+func Print(w io.Writer, conf Config) error {
+	tabs := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, setting := range Settings() {
+		value, ok := os.LookupEnv(setting.Key)
+		source := "default"
+		if s, set := conf.Sources[setting.Key]; set {
+			source = s.String()
+		}
+		if !ok {
+			value = setting.Default
+		}
+		if setting.Redact && value != "" {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(tabs, "%s=%s\t# %s\n", setting.Key, value, source); err != nil {
+			return err
+		}
+	}
+	return tabs.Flush()
+}
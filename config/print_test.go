@@ -0,0 +1,26 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrint(t *testing.T) {
+	t.Setenv("SCREEGO_SECRET", "very secret")
+	t.Setenv("SCREEGO_SERVER_ADDRESS", "127.0.0.1:5050")
+
+	var buf bytes.Buffer
+	err := Print(&buf, Config{Sources: map[string]Source{
+		"SCREEGO_SECRET":         {},
+		"SCREEGO_SERVER_ADDRESS": {File: "screego.yml", Line: 2},
+	}})
+	assert.NoError(t, err)
+
+	out := buf.String()
+	assert.NotContains(t, out, "very secret")
+	assert.Regexp(t, `SCREEGO_SECRET=<redacted> +# environment\n`, out)
+	assert.Regexp(t, `SCREEGO_SERVER_ADDRESS=127.0.0.1:5050 +# screego.yml:2\n`, out)
+	assert.Regexp(t, `SCREEGO_TURN_CREDENTIAL_TTL=10m +# default\n`, out)
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Live holds the current config. Reload replaces the settings which are safe to change at runtime, all others keep
+// their value from the start because they require a restart.
+type Live struct {
+	file    string
+	lock    sync.Mutex
+	current atomic.Pointer[Config]
+}
+
+// NewLive creates a Live config, file is the YAML config file passed to Get.
+func NewLive(conf Config, file string) *Live {
+	live := &Live{file: file}
+	live.current.Store(&conf)
+	return live
+}
+
+// Get returns the current config.
+func (l *Live) Get() Config {
+	return *l.current.Load()
+}
+
+// Reload loads the config again and applies the settings which are safe to change. It returns a description of the
+// changed settings. On errors, the current config is kept.
+func (l *Live) Reload() ([]string, []FutureLog, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	loaded, logs := Get(l.file)
+	var fatal []string
+	for _, log := range logs {
+		if log.Level == zerolog.FatalLevel || log.Level == zerolog.PanicLevel {
+			fatal = append(fatal, log.Msg)
+		}
+	}
+	if len(fatal) > 0 {
+		return nil, logs, errors.New(strings.Join(fatal, "; "))
+	}
+
+	current := l.current.Load()
+	next := *current
+	next.CorsAllowedOrigins = loaded.CorsAllowedOrigins
+	next.CheckOrigin = loaded.CheckOrigin
+	next.TurnDenyPeers = loaded.TurnDenyPeers
+	next.TurnDenyPeersParsed = loaded.TurnDenyPeersParsed
+	next.CloseRoomWhenOwnerLeaves = loaded.CloseRoomWhenOwnerLeaves
+	next.LogLevel = loaded.LogLevel
+
+	changes := []string{}
+	changed := func(key string, before, after any) {
+		if fmt.Sprint(before) != fmt.Sprint(after) {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", key, before, after))
+		}
+	}
+	changed("SCREEGO_CORS_ALLOWED_ORIGINS", current.CorsAllowedOrigins, next.CorsAllowedOrigins)
+	changed("SCREEGO_TURN_DENY_PEERS", current.TurnDenyPeers, next.TurnDenyPeers)
+	changed("SCREEGO_CLOSE_ROOM_WHEN_OWNER_LEAVES", current.CloseRoomWhenOwnerLeaves, next.CloseRoomWhenOwnerLeaves)
+	changed("SCREEGO_LOG_LEVEL", current.LogLevel.AsZeroLogLevel(), next.LogLevel.AsZeroLogLevel())
+
+	l.current.Store(&next)
+	return changes, logs, nil
+}
+
+// SetTurnPorts sets the ports of the TURN listeners, the ports of systemd sockets are only known after listening.
+func (l *Live) SetTurnPorts(port, tlsPort string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	next := *l.current.Load()
+	next.TurnPort = port
+	next.TurnTLSPort = tlsPort
+	l.current.Store(&next)
+}
+
+// loadedKeys contains the environment variables which were set from files by Get, they are removed before the files
+// are loaded again, so that changed files are applied.
+var loadedKeys []string
+
+func unsetLoadedKeys() {
+	for _, key := range loadedKeys {
+		_ = os.Unsetenv(key)
+	}
+	loadedKeys = nil
+}
+
+func setLoadedKey(key, value string) {
+	_ = os.Setenv(key, value)
+	if !slices.Contains(loadedKeys, key) {
+		loadedKeys = append(loadedKeys, key)
+	}
+}
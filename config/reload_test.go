@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLive_Reload(t *testing.T) {
+	file := writeFile(t, `
+external_ip: 127.0.0.1
+secret: secret
+server_address: 127.0.0.1:5050
+log_level: info
+close_room_when_owner_leaves: true
+`)
+	conf, logs := Get(file)
+	for _, log := range logs {
+		require.NotEqual(t, zerolog.FatalLevel, log.Level, log.Msg)
+	}
+	live := NewLive(conf, file)
+
+	require.NoError(t, os.WriteFile(file, []byte(`
+external_ip: 127.0.0.1
+secret: secret
+server_address: 127.0.0.1:6060
+log_level: debug
+close_room_when_owner_leaves: false
+cors_allowed_origins: [https://example.org]
+`), 0o600))
+
+	changes, _, err := live.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SCREEGO_CORS_ALLOWED_ORIGINS: [] -> [https://example.org]",
+		"SCREEGO_CLOSE_ROOM_WHEN_OWNER_LEAVES: true -> false",
+		"SCREEGO_LOG_LEVEL: info -> debug",
+	}, changes)
+
+	current := live.Get()
+	assert.Equal(t, []string{"127.0.0.1:5050"}, current.ServerAddress, "requires a restart")
+	assert.False(t, current.CloseRoomWhenOwnerLeaves)
+	assert.True(t, current.CheckOrigin("https://example.org"))
+	assert.False(t, current.CheckOrigin("https://other.org"))
+}
+
+func TestLive_Reload_invalid(t *testing.T) {
+	file := writeFile(t, `
+external_ip: 127.0.0.1
+secret: secret
+log_level: info
+`)
+	conf, _ := Get(file)
+	live := NewLive(conf, file)
+
+	require.NoError(t, os.WriteFile(file, []byte(`
+external_ip: 127.0.0.1
+secret: secret
+log_level: debug
+auth_mode: abc
+`), 0o600))
+
+	_, _, err := live.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, zerolog.InfoLevel, live.Get().LogLevel.AsZeroLogLevel())
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Secrets is an ordered list of secrets, the first is used for signing and all are accepted for validation.
+type Secrets [][]byte
+
+// Decode parses secrets separated by commas or newlines, it's used for SCREEGO_SECRETS. SCREEGO_SECRET is never split
+// to keep existing secrets containing a comma valid.
+func (s *Secrets) Decode(value string) error {
+	*s = nil
+	for _, secret := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			*s = append(*s, []byte(secret))
+		}
+	}
+	return nil
+}
+
+// ReadSecretFile reads a secret from a file, f.ex. a mounted docker or kubernetes secret. Surrounding whitespace is
+// removed.
+func ReadSecretFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", errors.New("file is empty")
+	}
+	return secret, nil
+}
+
+// WatchSecretFile reads the secret file in the interval and calls onChange when the secret was rotated.
+func WatchSecretFile(path string, interval time.Duration, onChange func(secret string)) {
+	current, _ := ReadSecretFile(path)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			secret, err := ReadSecretFile(path)
+			if err != nil {
+				log.Warn().Err(err).Str("file", path).Msg("Cannot read secret file, keeping the current secret")
+				continue
+			}
+			if secret != current {
+				current = secret
+				log.Info().Str("file", path).Msg("Secret file changed")
+				onChange(secret)
+			}
+		}
+	}()
+}
+
+// secretFromFile returns the secret from the file of the key, it's empty when the file isn't set.
+func secretFromFile(set bool, file, key string) (string, []FutureLog) {
+	if file == "" {
+		return "", nil
+	}
+	if set {
+		return "", []FutureLog{futureFatal(fmt.Sprintf("%s and %s_FILE must not be both set", key, key))}
+	}
+	value, err := ReadSecretFile(file)
+	if err != nil {
+		return "", []FutureLog{futureFatal(fmt.Sprintf("cannot read %s_FILE: %s", key, err))}
+	}
+	return value, nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretFromFile(t *testing.T) {
+	file := writeFile(t, "  rotated-secret\n")
+
+	secret, logs := secretFromFile(false, file, "SCREEGO_SECRET")
+	assert.Empty(t, logs)
+	assert.Equal(t, "rotated-secret", secret)
+
+	secret, logs = secretFromFile(true, "", "SCREEGO_SECRET")
+	assert.Empty(t, logs)
+	assert.Empty(t, secret)
+}
+
+func TestSecretFromFile_invalid(t *testing.T) {
+	_, logs := secretFromFile(true, writeFile(t, "secret"), "SCREEGO_SECRET")
+	require.Len(t, logs, 1)
+	assert.Equal(t, "SCREEGO_SECRET and SCREEGO_SECRET_FILE must not be both set", logs[0].Msg)
+
+	_, logs = secretFromFile(false, writeFile(t, "\n"), "SCREEGO_SECRET")
+	require.Len(t, logs, 1)
+	assert.Contains(t, logs[0].Msg, "cannot read SCREEGO_SECRET_FILE: file is empty")
+}
+
+func TestGet_secretFile(t *testing.T) {
+	secretFile := writeFile(t, "from-file\n")
+	file := writeFile(t, `
+turn_external_ip: 127.0.0.1
+turn_external_secret_file: `+secretFile+`
+secret_file: `+secretFile+`
+`)
+
+	conf, logs := Get(file)
+	for _, log := range logs {
+		assert.NotContains(t, log.Msg, "SECRET", log.Msg)
+	}
+	assert.Equal(t, Secrets{[]byte("from-file")}, conf.SessionSecrets)
+	assert.Equal(t, "from-file", conf.TurnExternalSecret)
+}
+
+func TestGet_secretIsNotSplit(t *testing.T) {
+	t.Setenv("SCREEGO_SECRET", " existing,secret ")
+	conf, _ := Get(writeFile(t, "external_ip: 127.0.0.1\n"))
+	assert.Equal(t, Secrets{[]byte(" existing,secret ")}, conf.SessionSecrets)
+}
+
+func TestGet_secrets(t *testing.T) {
+	t.Setenv("SCREEGO_SECRETS", "new,old")
+	conf, logs := Get(writeFile(t, "external_ip: 127.0.0.1\n"))
+	for _, log := range logs {
+		assert.NotContains(t, log.Msg, "SECRET", log.Msg)
+	}
+	assert.Equal(t, Secrets{[]byte("new"), []byte("old")}, conf.SessionSecrets)
+
+	t.Setenv("SCREEGO_SECRET", "secret")
+	_, logs = Get(writeFile(t, "external_ip: 127.0.0.1\n"))
+	require.NotEmpty(t, logs)
+	assert.Contains(t, logs[0].Msg, "SCREEGO_SECRETS and SCREEGO_SECRET/SCREEGO_SECRET_FILE must not be both set")
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"slices"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	TLSClientAuthNone     = "none"
+	TLSClientAuthOptional = "optional"
+	TLSClientAuthRequired = "required"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func (c *Config) parseTLS() []FutureLog {
+	var logs []FutureLog
+
+	version, ok := tlsVersions[c.TLSMinVersion]
+	if !ok {
+		logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TLS_MIN_VERSION: %s must be one of 1.0, 1.1, 1.2, 1.3", c.TLSMinVersion)))
+	}
+	c.TLSMinVersionParsed = version
+
+	c.TLSCipherSuitesParsed = nil
+	for _, name := range c.TLSCipherSuites {
+		if id, ok := cipherSuite(tls.CipherSuites(), name); ok {
+			c.TLSCipherSuitesParsed = append(c.TLSCipherSuitesParsed, id)
+		} else if id, ok := cipherSuite(tls.InsecureCipherSuites(), name); ok {
+			c.TLSCipherSuitesParsed = append(c.TLSCipherSuitesParsed, id)
+			logs = append(logs, FutureLog{
+				Level: zerolog.WarnLevel,
+				Msg:   fmt.Sprintf("SCREEGO_TLS_CIPHER_SUITES: %s is insecure", name),
+			})
+		} else {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TLS_CIPHER_SUITES: unknown cipher suite %s", name)))
+		}
+	}
+	if len(c.TLSCipherSuites) > 0 && version == tls.VersionTLS13 {
+		logs = append(logs, FutureLog{
+			Level: zerolog.WarnLevel,
+			Msg:   "SCREEGO_TLS_CIPHER_SUITES is ignored, the cipher suites of TLS 1.3 are not configurable",
+		})
+	}
+
+	switch c.TLSClientAuth {
+	case TLSClientAuthNone:
+		c.TLSClientAuthParsed = tls.NoClientCert
+	case TLSClientAuthOptional:
+		c.TLSClientAuthParsed = tls.VerifyClientCertIfGiven
+	case TLSClientAuthRequired:
+		c.TLSClientAuthParsed = tls.RequireAndVerifyClientCert
+	default:
+		logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_TLS_CLIENT_AUTH: %s", c.TLSClientAuth)))
+	}
+	if c.TLSClientAuth != TLSClientAuthNone && c.TLSClientCAFile == "" {
+		logs = append(logs, futureFatal("SCREEGO_TLS_CLIENT_CA_FILE must be set if SCREEGO_TLS_CLIENT_AUTH is enabled"))
+	}
+	if c.TLSClientAuth != TLSClientAuthNone && c.UsersFile == "" {
+		logs = append(logs, FutureLog{
+			Level: zerolog.WarnLevel,
+			Msg:   "SCREEGO_USERS_FILE is unset, client certificates of SCREEGO_TLS_CLIENT_AUTH won't log in any user",
+		})
+	}
+
+	return logs
+}
+
+func cipherSuite(suites []*tls.CipherSuite, name string) (uint16, bool) {
+	index := slices.IndexFunc(suites, func(suite *tls.CipherSuite) bool {
+		return suite.Name == name
+	})
+	if index == -1 {
+		return 0, false
+	}
+	return suites[index].ID, true
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTLS(t *testing.T) {
+	conf := Config{
+		TLSMinVersion:   "1.2",
+		TLSCipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"},
+		TLSClientAuth:   TLSClientAuthOptional,
+		TLSClientCAFile: "ca.pem",
+		UsersFile:       "users",
+	}
+	assert.Empty(t, conf.parseTLS())
+	assert.Equal(t, uint16(tls.VersionTLS12), conf.TLSMinVersionParsed)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}, conf.TLSCipherSuitesParsed)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, conf.TLSClientAuthParsed)
+}
+
+func TestParseTLS_invalid(t *testing.T) {
+	conf := Config{
+		TLSMinVersion:   "1.4",
+		TLSCipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA", "unknown"},
+		TLSClientAuth:   TLSClientAuthRequired,
+	}
+	logs := conf.parseTLS()
+	require.Len(t, logs, 5)
+	assert.Equal(t, "invalid SCREEGO_TLS_MIN_VERSION: 1.4 must be one of 1.0, 1.1, 1.2, 1.3", logs[0].Msg)
+	assert.Equal(t, "SCREEGO_TLS_CIPHER_SUITES: TLS_RSA_WITH_RC4_128_SHA is insecure", logs[1].Msg)
+	assert.Equal(t, "invalid SCREEGO_TLS_CIPHER_SUITES: unknown cipher suite unknown", logs[2].Msg)
+	assert.Equal(t, "SCREEGO_TLS_CLIENT_CA_FILE must be set if SCREEGO_TLS_CLIENT_AUTH is enabled", logs[3].Msg)
+	assert.Equal(t, "SCREEGO_USERS_FILE is unset, client certificates of SCREEGO_TLS_CLIENT_AUTH won't log in any user", logs[4].Msg)
+}
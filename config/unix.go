@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+func (c *Config) parseUnixSocket() []FutureLog {
+	var logs []FutureLog
+
+	if c.UnixSocketMode != "" {
+		mode, err := strconv.ParseUint(c.UnixSocketMode, 8, 32)
+		if err != nil || mode > 0o777 {
+			logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_UNIX_SOCKET_MODE: %s must be an octal file mode like 0660", c.UnixSocketMode)))
+		}
+		c.UnixSocketModeParsed = os.FileMode(mode)
+	}
+
+	c.UnixSocketUID, c.UnixSocketGID = -1, -1
+	if c.UnixSocketOwner != "" {
+		owner, group, _ := strings.Cut(c.UnixSocketOwner, ":")
+		var err error
+		if owner != "" {
+			if c.UnixSocketUID, err = lookupID(owner, func(name string) (string, error) {
+				u, err := user.Lookup(name)
+				if err != nil {
+					return "", err
+				}
+				return u.Uid, nil
+			}); err != nil {
+				logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_UNIX_SOCKET_OWNER: %s", err)))
+			}
+		}
+		if group != "" {
+			if c.UnixSocketGID, err = lookupID(group, func(name string) (string, error) {
+				g, err := user.LookupGroup(name)
+				if err != nil {
+					return "", err
+				}
+				return g.Gid, nil
+			}); err != nil {
+				logs = append(logs, futureFatal(fmt.Sprintf("invalid SCREEGO_UNIX_SOCKET_OWNER: %s", err)))
+			}
+		}
+	}
+
+	return logs
+}
+
+// lookupID returns numeric ids as is and looks up names.
+func lookupID(name string, lookup func(name string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	id, err := lookup(name)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(id)
+}
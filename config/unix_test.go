@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnixSocket(t *testing.T) {
+	conf := Config{UnixSocketMode: "0660", UnixSocketOwner: "1000:root"}
+	assert.Empty(t, conf.parseUnixSocket())
+	assert.Equal(t, os.FileMode(0o660), conf.UnixSocketModeParsed)
+	assert.Equal(t, 1000, conf.UnixSocketUID)
+	assert.Equal(t, 0, conf.UnixSocketGID)
+
+	conf = Config{UnixSocketOwner: ":1000"}
+	assert.Empty(t, conf.parseUnixSocket())
+	assert.Equal(t, -1, conf.UnixSocketUID, "the owner is kept")
+	assert.Equal(t, 1000, conf.UnixSocketGID)
+}
+
+func TestParseUnixSocket_invalid(t *testing.T) {
+	conf := Config{UnixSocketMode: "0999", UnixSocketOwner: "screego-unknown-user"}
+	logs := conf.parseUnixSocket()
+	require.Len(t, logs, 2)
+	assert.Equal(t, "invalid SCREEGO_UNIX_SOCKET_MODE: 0999 must be an octal file mode like 0660", logs[0].Msg)
+	assert.Contains(t, logs[1].Msg, "invalid SCREEGO_UNIX_SOCKET_OWNER: ")
+}
+
+func TestGet_systemdTurnAddressWithoutSockets(t *testing.T) {
+	conf, logs := Get(writeFile(t, "external_ip: 127.0.0.1\nturn_address: systemd:turn\n"))
+	for _, log := range logs {
+		assert.NotContains(t, log.Msg, "SCREEGO_TURN_ADDRESS", "the socket is only required when the TURN server is started")
+	}
+	assert.Empty(t, conf.TurnPort)
+}
@@ -10,6 +10,12 @@ import (
 
 // Init initializes the logger.
 func Init(lvl zerolog.Level) {
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}).Level(lvl)
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
+	SetLevel(lvl)
 	log.Debug().Msg("Logger initialized")
 }
+
+// SetLevel changes the log level, it's safe to call while logging.
+func SetLevel(lvl zerolog.Level) {
+	zerolog.SetGlobalLevel(lvl)
+}
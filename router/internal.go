@@ -0,0 +1,122 @@
+package router
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/hlog"
+	"github.com/screego/server/audit"
+	"github.com/screego/server/auth"
+	"github.com/screego/server/config"
+	"github.com/screego/server/util"
+	"github.com/screego/server/ws"
+)
+
+// Reload reloads the config and returns the changed settings.
+type Reload func(actor audit.Actor) ([]string, error)
+
+type ReloadResponse struct {
+	Changes []string `json:"changes"`
+}
+
+// Internal creates the router for the internal listener, which exposes metrics, health, pprof diagnostics and
+// the admin api.
+// It isn't meant to be reachable from the internet.
+func Internal(conf config.Config, rooms *ws.Rooms, users *auth.Users, reload Reload) *mux.Router {
+	router := mux.NewRouter()
+	router.Use(hlog.AccessHandler(accessLogger))
+	router.Use(func(handler http.Handler) http.Handler {
+		return internalAuth(handler, conf, users)
+	})
+
+	router.Methods("GET").Path("/metrics").Handler(promhttp.Handler())
+	router.Methods("GET").Path("/health").HandlerFunc(health(rooms))
+
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(func(handler http.Handler) http.Handler {
+		return adminAuth(handler, conf)
+	})
+	admin.Methods("GET").Path("/sessions").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessions, err := rooms.Sessions()
+		if err != nil {
+			w.WriteHeader(500)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sessions)
+	})
+	admin.Methods("POST").Path("/reload").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		account, _, _ := r.BasicAuth()
+		changes, err := reload(audit.Actor{Account: account, IP: util.RemoteIP(r, conf.TrustProxyHeaders).String()})
+		if err != nil {
+			w.WriteHeader(500)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&ReloadResponse{Changes: changes})
+	})
+
+	debug := router.PathPrefix("/debug/pprof").Subrouter()
+	debug.Use(func(handler http.Handler) http.Handler {
+		return adminAuth(handler, conf)
+	})
+	debug.HandleFunc("/cmdline", pprof.Cmdline)
+	debug.HandleFunc("/profile", pprof.Profile)
+	debug.HandleFunc("/symbol", pprof.Symbol)
+	debug.HandleFunc("/trace", pprof.Trace)
+	debug.PathPrefix("/").HandlerFunc(pprof.Index)
+
+	return router
+}
+
+func internalAuth(handler http.Handler, conf config.Config, users *auth.Users) http.Handler {
+	switch conf.MetricsAuthMode {
+	case config.MetricsAuthUsers:
+		return basicAuth(handler, users)
+	case config.MetricsAuthToken:
+		return bearerAuth(handler, conf.MetricsToken)
+	default:
+		return handler
+	}
+}
+
+// adminAuth restricts the admin api and pprof to SCREEGO_ADMIN_USERS when the users of the users file are authenticated, they
+// are the same users which log in to screego.
+func adminAuth(handler http.Handler, conf config.Config) http.Handler {
+	if conf.MetricsAuthMode != config.MetricsAuthUsers {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ := r.BasicAuth()
+		if !slices.Contains(conf.AdminUsers, user) {
+			w.WriteHeader(403)
+			_, _ = w.Write([]byte("Forbidden.\n"))
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func bearerAuth(handler http.Handler, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="screego"`)
+			w.WriteHeader(401)
+			_, _ = w.Write([]byte("Unauthorized.\n"))
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}
+}
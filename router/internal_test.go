@@ -0,0 +1,44 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/screego/server/audit"
+	"github.com/screego/server/auth"
+	"github.com/screego/server/config"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestInternal_adminAndPprofRequireAdminUser(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	users := &auth.Users{Lookup: map[string]string{"admin": string(hash), "user1": string(hash)}}
+	reload := func(actor audit.Actor) ([]string, error) {
+		return nil, nil
+	}
+	router := Internal(config.Config{MetricsAuthMode: config.MetricsAuthUsers, AdminUsers: []string{"admin"}}, nil, users, reload)
+
+	for _, tc := range []struct {
+		user   string
+		method string
+		path   string
+		status int
+	}{
+		{user: "user1", method: http.MethodGet, path: "/metrics", status: 200},
+		{user: "user1", method: http.MethodPost, path: "/admin/reload", status: 403},
+		{user: "admin", method: http.MethodPost, path: "/admin/reload", status: 200},
+		{user: "unknown", method: http.MethodPost, path: "/admin/reload", status: 401},
+		{user: "user1", method: http.MethodGet, path: "/debug/pprof/", status: 403},
+		{user: "user1", method: http.MethodGet, path: "/debug/pprof/profile", status: 403},
+		{user: "admin", method: http.MethodGet, path: "/debug/pprof/", status: 200},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.SetBasicAuth(tc.user, "pass")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, tc.status, recorder.Code, "%s %s %s", tc.user, tc.method, tc.path)
+	}
+}
@@ -31,14 +31,19 @@ type UIConfig struct {
 	CloseRoomWhenOwnerLeaves bool   `json:"closeRoomWhenOwnerLeaves"`
 }
 
-func Router(conf config.Config, rooms *ws.Rooms, users *auth.Users, version string) *mux.Router {
+func Router(live *config.Live, rooms *ws.Rooms, users *auth.Users, version string) *mux.Router {
+	conf := live.Get()
 	router := mux.NewRouter()
 	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// https://github.com/gorilla/mux/issues/416
 		accessLogger(r, 404, 0, 0)
 	})
 	router.Use(hlog.AccessHandler(accessLogger))
-	router.Use(handlers.CORS(handlers.AllowedMethods([]string{"GET", "POST"}), handlers.AllowedOriginValidator(conf.CheckOrigin)))
+	checkOrigin := func(origin string) bool {
+		// the allowed origins can be changed at runtime via config reload.
+		return live.Get().CheckOrigin(origin)
+	}
+	router.Use(handlers.CORS(handlers.AllowedMethods([]string{"GET", "POST"}), handlers.AllowedOriginValidator(checkOrigin)))
 	router.HandleFunc("/stream", rooms.Upgrade)
 	router.Methods("POST").Path("/login").HandlerFunc(users.Authenticate)
 	router.Methods("POST").Path("/logout").HandlerFunc(users.Logout)
@@ -50,30 +55,37 @@ func Router(conf config.Config, rooms *ws.Rooms, users *auth.Users, version stri
 			User:                     user,
 			Version:                  version,
 			RoomName:                 rooms.RandRoomName(),
-			CloseRoomWhenOwnerLeaves: conf.CloseRoomWhenOwnerLeaves,
+			CloseRoomWhenOwnerLeaves: live.Get().CloseRoomWhenOwnerLeaves,
 		})
 	})
-	router.Methods("GET").Path("/health").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	router.Methods("GET").Path("/health").HandlerFunc(health(rooms))
+	if conf.Prometheus && conf.MetricsAddress == "" {
+		log.Info().Msg("Prometheus enabled")
+		router.Methods("GET").Path("/metrics").Handler(basicAuth(promhttp.Handler(), users))
+	}
+
+	ui.Register(router)
+
+	return router
+}
+
+func health(rooms *ws.Rooms) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		i, err := rooms.Count()
 		status := "up"
 		if err != "" {
 			status = "down"
 			w.WriteHeader(500)
+		} else if rooms.Draining() {
+			status = "draining"
+			w.WriteHeader(503)
 		}
 		_ = json.NewEncoder(w).Encode(Health{
 			Status:  status,
 			Clients: i,
 			Reason:  err,
 		})
-	})
-	if conf.Prometheus {
-		log.Info().Msg("Prometheus enabled")
-		router.Methods("GET").Path("/metrics").Handler(basicAuth(promhttp.Handler(), users))
 	}
-
-	ui.Register(router)
-
-	return router
 }
 
 func accessLogger(r *http.Request, status, size int, dur time.Duration) {
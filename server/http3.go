@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/rs/zerolog/log"
+)
+
+// serveHTTP3 serves the handler of srv via HTTP/3 on the udp address and advertises it to the clients of srv via the
+// Alt-Svc header. The HTTP/3 server is closed together with srv.
+//
+// Websockets aren't supported via HTTP/3, browsers use HTTP/1.1 for them.
+func serveHTTP3(srv *http.Server, address string, shutdown chan<- error) error {
+	conn, err := listenPacket(address)
+	if err != nil {
+		return err
+	}
+
+	h3 := &http3.Server{
+		Handler:   srv.Handler,
+		TLSConfig: http3.ConfigureTLSConfig(srv.TLSConfig),
+	}
+	srv.RegisterOnShutdown(func() {
+		_ = h3.Close()
+	})
+	srv.Handler = altSvc(h3, srv.Handler)
+
+	go func() {
+		log.Info().Str("addr", conn.LocalAddr().String()).Msg("Start HTTP/3")
+		err := h3.Serve(conn)
+		// the server doesn't close connections passed to it.
+		_ = conn.Close()
+		shutdown <- err
+	}()
+	return nil
+}
+
+func altSvc(h3 *http3.Server, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fails only until the HTTP/3 listener is started.
+		_ = h3.SetQUICHeaders(w.Header())
+		handler.ServeHTTP(w, r)
+	})
+}
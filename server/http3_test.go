@@ -0,0 +1,87 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartServer_http3(t *testing.T) {
+	address := "127.0.0.1:" + strconv.Itoa(port())
+	udp := "127.0.0.1:" + strconv.Itoa(udpPort())
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{certificate(t)}}
+	srv, shutdown := startServer(handler, Listen{Addresses: []string{address}, HTTP3Address: udp}, tlsConfig)
+
+	insecure := &tls.Config{InsecureSkipVerify: true}
+	https := &http.Client{Transport: &http.Transport{TLSClientConfig: insecure}}
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		resp, err := https.Get("https://" + address)
+		if !assert.NoError(c, err) {
+			return
+		}
+		_ = resp.Body.Close()
+		_, port, _ := net.SplitHostPort(udp)
+		assert.Equal(c, `h3=":`+port+`"; ma=2592000`, resp.Header.Get("Alt-Svc"))
+	}, time.Second, 10*time.Millisecond)
+
+	transport := &http3.Transport{TLSClientConfig: insecure}
+	defer transport.Close()
+	assert.Equal(t, "HTTP/3.0", get(t, &http.Client{Transport: transport}, "https://"+udp))
+
+	require.NoError(t, srv.Shutdown(t.Context()))
+	// the http and the HTTP/3 listener
+	assert.ErrorIs(t, <-shutdown, http.ErrServerClosed)
+	assert.ErrorIs(t, <-shutdown, http.ErrServerClosed)
+	conn, err := net.ListenPacket("udp", udp)
+	require.NoError(t, err, "the HTTP/3 listener is closed")
+	_ = conn.Close()
+}
+
+func TestStartServer_http3ListenError(t *testing.T) {
+	address := "127.0.0.1:" + strconv.Itoa(port())
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{certificate(t)}}
+	_, shutdown := startServer(http.NotFoundHandler(), Listen{Addresses: []string{address}, HTTP3Address: ":-5"}, tlsConfig)
+	assert.Error(t, <-shutdown)
+
+	listener, err := net.Listen("tcp", address)
+	require.NoError(t, err, "the http listener is closed")
+	_ = listener.Close()
+}
+
+func udpPort() int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func certificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/screego/server/systemd"
+)
+
+// Listen describes the addresses the server listens on.
+type Listen struct {
+	// Addresses in the formats host:port, unix:path or systemd:name.
+	Addresses []string
+	// UnixSocket contains the permissions of unix sockets, it may be nil.
+	UnixSocket *UnixSocket
+	// HTTP2Cleartext accepts HTTP/2 without TLS (h2c), f.ex. from reverse proxies.
+	HTTP2Cleartext bool
+	// HTTP3Address is the udp address in the formats host:port or systemd:name of the HTTP/3 listener. It's only
+	// used with TLS.
+	HTTP3Address string
+}
+
+// UnixSocket contains the permissions of unix sockets.
+type UnixSocket struct {
+	// Mode is applied if it isn't 0.
+	Mode os.FileMode
+	// UID and GID are applied if they aren't -1.
+	UID int
+	GID int
+}
+
+// listeners creates the listeners of all addresses.
+func (l Listen) listeners() ([]net.Listener, error) {
+	var result []net.Listener
+	for _, address := range l.Addresses {
+		listeners, err := l.listen(address)
+		if err != nil {
+			for _, listener := range result {
+				_ = listener.Close()
+			}
+			return nil, err
+		}
+		result = append(result, listeners...)
+	}
+	return result, nil
+}
+
+func (l Listen) listen(address string) ([]net.Listener, error) {
+	if name, ok := strings.CutPrefix(address, systemd.Prefix); ok {
+		listeners, packetConns, err := systemd.Listen(name)
+		if len(packetConns) > 0 {
+			return nil, fmt.Errorf("systemd socket %s must be a stream socket", name)
+		}
+		return listeners, err
+	}
+
+	path, ok := strings.CutPrefix(address, "unix:")
+	if !ok {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, err
+		}
+		return []net.Listener{listener}, nil
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.UnixSocket.apply(path); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return []net.Listener{listener}, nil
+}
+
+func listenPacket(address string) (net.PacketConn, error) {
+	if name, ok := strings.CutPrefix(address, systemd.Prefix); ok {
+		listeners, packetConns, err := systemd.Listen(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(listeners) > 0 || len(packetConns) != 1 {
+			return nil, fmt.Errorf("systemd socket %s must be a single datagram socket", name)
+		}
+		return packetConns[0], nil
+	}
+	return net.ListenPacket("udp", address)
+}
+
+func (s *UnixSocket) apply(path string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Mode != 0 {
+		if err := os.Chmod(path, s.Mode); err != nil {
+			return err
+		}
+	}
+	if s.UID != -1 || s.GID != -1 {
+		return os.Chown(path, s.UID, s.GID)
+	}
+	return nil
+}
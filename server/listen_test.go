@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListen_multipleAddresses(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "screego.socket")
+	listen := Listen{
+		Addresses:  []string{"127.0.0.1:" + strconv.Itoa(port()), "unix:" + socket},
+		UnixSocket: &UnixSocket{Mode: 0o660, UID: -1, GID: -1},
+	}
+
+	listeners, err := listen.listeners()
+	require.NoError(t, err)
+	defer func() {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+	}()
+	require.Len(t, listeners, 2)
+	assert.IsType(t, &net.TCPListener{}, listeners[0])
+	assert.IsType(t, &net.UnixListener{}, listeners[1])
+
+	info, err := os.Stat(socket)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+}
+
+func TestListen_closesOnError(t *testing.T) {
+	address := "127.0.0.1:" + strconv.Itoa(port())
+	_, err := Listen{Addresses: []string{address, ":-5"}}.listeners()
+	require.Error(t, err)
+
+	listener, err := net.Listen("tcp", address)
+	require.NoError(t, err, "the listener of the first address is closed")
+	_ = listener.Close()
+}
+
+func TestListen_systemdMissing(t *testing.T) {
+	_, err := Listen{Addresses: []string{"systemd:http"}}.listeners()
+	assert.EqualError(t, err, "no systemd socket named http, set FileDescriptorName=http in the socket unit")
+}
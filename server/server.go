@@ -2,14 +2,14 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"syscall"
 	"time"
 
-	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
 )
 
@@ -20,56 +20,73 @@ var (
 	}
 )
 
-// Start starts the http server.
-func Start(mux *mux.Router, address, cert, key string) error {
-	server, shutdown := startServer(mux, address, cert, key)
-	shutdownOnInterruptSignal(server, 2*time.Second, shutdown)
+// Start starts the http server, tlsConfig is nil for plain http. On SIGINT or SIGTERM drain is called before the
+// server shuts down, it may be nil.
+func Start(handler http.Handler, listen Listen, tlsConfig *tls.Config, drain func()) error {
+	server, shutdown := startServer(handler, listen, tlsConfig)
+	shutdownOnInterruptSignal(server, 2*time.Second, drain, shutdown)
 	return waitForServerToClose(shutdown)
 }
 
-func startServer(mux *mux.Router, address, cert, key string) (*http.Server, chan error) {
+func startServer(handler http.Handler, listen Listen, tlsConfig *tls.Config) (*http.Server, chan error) {
 	srv := &http.Server{
-		Addr:    address,
-		Handler: mux,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+	if listen.HTTP2Cleartext {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
 	}
 
-	shutdown := make(chan error)
-	go func() {
-		err := listenAndServe(srv, address, cert, key)
+	listeners, err := listen.listeners()
+	// each listener, the HTTP/3 listener and the shutdown may report an error.
+	shutdown := make(chan error, len(listeners)+2)
+	if err != nil {
 		shutdown <- err
-	}()
-	return srv, shutdown
-}
-
-func listenAndServe(srv *http.Server, address, cert, key string) error {
-	var err error
-	var listener net.Listener
-
-	if strings.HasPrefix(address, "unix:") {
-		listener, err = net.Listen("unix", strings.TrimPrefix(address, "unix:"))
-	} else {
-		listener, err = net.Listen("tcp", address)
+		return srv, shutdown
 	}
-	if err != nil {
-		return err
+	if listen.HTTP3Address != "" && tlsConfig != nil {
+		if err := serveHTTP3(srv, listen.HTTP3Address, shutdown); err != nil {
+			for _, listener := range listeners {
+				_ = listener.Close()
+			}
+			shutdown <- err
+			return srv, shutdown
+		}
 	}
+	for _, listener := range listeners {
+		go func() {
+			shutdown <- serve(srv, listener, tlsConfig != nil)
+		}()
+	}
+	return srv, shutdown
+}
 
-	if cert != "" || key != "" {
-		log.Info().Str("addr", address).Msg("Start HTTP with tls")
-		return srv.ServeTLS(listener, cert, key)
+// serve serves on the listener, useTLS is passed explicitly because the server sets a default TLSConfig when
+// serving HTTP/2.
+func serve(srv *http.Server, listener net.Listener, useTLS bool) error {
+	if useTLS {
+		log.Info().Str("addr", listener.Addr().String()).Msg("Start HTTP with tls")
+		// the certificates are provided by the tls config.
+		return srv.ServeTLS(listener, "", "")
 	} else {
-		log.Info().Str("addr", address).Msg("Start HTTP")
+		log.Info().Str("addr", listener.Addr().String()).Msg("Start HTTP")
 		return srv.Serve(listener)
 	}
 }
 
-func shutdownOnInterruptSignal(server *http.Server, timeout time.Duration, shutdown chan<- error) {
+func shutdownOnInterruptSignal(server *http.Server, timeout time.Duration, drain func(), shutdown chan<- error) {
 	interrupt := make(chan os.Signal, 1)
-	notifySignal(interrupt, os.Interrupt)
+	notifySignal(interrupt, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-interrupt
 		log.Info().Msg("Received interrupt. Shutting down...")
+		if drain != nil {
+			drain()
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
 		if err := serverShutdown(server, ctx); err != nil {
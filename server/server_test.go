@@ -3,15 +3,18 @@ package server
 import (
 	"context"
 	"errors"
+	"io"
 	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestShutdownOnErrorWhileShutdown(t *testing.T) {
@@ -25,7 +28,7 @@ func TestShutdownOnErrorWhileShutdown(t *testing.T) {
 	finished := make(chan error)
 
 	go func() {
-		finished <- Start(mux.NewRouter(), ":"+strconv.Itoa(port()), "", "")
+		finished <- Start(mux.NewRouter(), Listen{Addresses: []string{":" + strconv.Itoa(port())}}, nil, nil)
 	}()
 
 	select {
@@ -36,11 +39,34 @@ func TestShutdownOnErrorWhileShutdown(t *testing.T) {
 	}
 }
 
+func TestShutdownDrainsBeforeShutdown(t *testing.T) {
+	dispose := fakeInterrupt(t)
+	defer dispose()
+
+	drained := false
+	finished := make(chan error)
+
+	go func() {
+		finished <- Start(mux.NewRouter(), Listen{Addresses: []string{":" + strconv.Itoa(port())}}, nil, func() {
+			time.Sleep(100 * time.Millisecond)
+			drained = true
+		})
+	}()
+
+	select {
+	case <-time.After(1 * time.Second):
+		t.Fatal("Server should be closed")
+	case err := <-finished:
+		assert.Nil(t, err)
+		assert.True(t, drained)
+	}
+}
+
 func TestShutdownAfterError(t *testing.T) {
 	finished := make(chan error)
 
 	go func() {
-		finished <- Start(mux.NewRouter(), ":-5", "", "")
+		finished <- Start(mux.NewRouter(), Listen{Addresses: []string{":-5"}}, nil, nil)
 	}()
 
 	select {
@@ -58,7 +84,7 @@ func TestShutdown(t *testing.T) {
 	finished := make(chan error)
 
 	go func() {
-		finished <- Start(mux.NewRouter(), ":"+strconv.Itoa(port()), "", "")
+		finished <- Start(mux.NewRouter(), Listen{Addresses: []string{":" + strconv.Itoa(port())}}, nil, nil)
 	}()
 
 	select {
@@ -69,10 +95,44 @@ func TestShutdown(t *testing.T) {
 	}
 }
 
+func TestStartServer_http2Cleartext(t *testing.T) {
+	address := "127.0.0.1:" + strconv.Itoa(port())
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	srv, shutdown := startServer(handler, Listen{Addresses: []string{address}, HTTP2Cleartext: true}, nil)
+	defer func() {
+		_ = srv.Close()
+		<-shutdown
+	}()
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	h2c := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	assert.Equal(t, "HTTP/2.0", get(t, h2c, "http://"+address))
+	// websockets use HTTP/1.1
+	assert.Equal(t, "HTTP/1.1", get(t, http.DefaultClient, "http://"+address))
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	var body []byte
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		resp, err := client.Get(url)
+		if !assert.NoError(c, err) {
+			return
+		}
+		defer resp.Body.Close()
+		body, err = io.ReadAll(resp.Body)
+		assert.NoError(c, err)
+	}, time.Second, 10*time.Millisecond)
+	return string(body)
+}
+
 func fakeInterrupt(t *testing.T) func() {
 	oldNotify := notifySignal
 	notifySignal = func(c chan<- os.Signal, sig ...os.Signal) {
 		assert.Contains(t, sig, os.Interrupt)
+		assert.Contains(t, sig, syscall.SIGTERM)
 		go func() {
 			time.Sleep(100 * time.Millisecond)
 			c <- os.Interrupt
@@ -0,0 +1,72 @@
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Prefix marks addresses of sockets passed via systemd socket activation, f.ex. systemd:http. The name is set via
+// FileDescriptorName= in the socket unit.
+const Prefix = "systemd:"
+
+// listenFdsStart is the first file descriptor passed by systemd, see sd_listen_fds(3).
+const listenFdsStart = 3
+
+type socket struct {
+	name string
+	file *os.File
+}
+
+var (
+	once    sync.Once
+	sockets []socket
+)
+
+// activated returns the sockets passed by systemd, they are only read once.
+func activated() []socket {
+	once.Do(func() {
+		if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+			return
+		}
+		count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+		if err != nil {
+			return
+		}
+		names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+		for i := range count {
+			name := "unknown"
+			if i < len(names) && names[i] != "" {
+				name = names[i]
+			}
+			fd := listenFdsStart + i
+			sockets = append(sockets, socket{name: name, file: os.NewFile(uintptr(fd), name)})
+		}
+	})
+	return sockets
+}
+
+// Listen returns the stream and datagram sockets with the name.
+func Listen(name string) ([]net.Listener, []net.PacketConn, error) {
+	var listeners []net.Listener
+	var packetConns []net.PacketConn
+	for _, s := range activated() {
+		if s.name != name {
+			continue
+		}
+		if listener, err := net.FileListener(s.file); err == nil {
+			listeners = append(listeners, listener)
+		} else if packetConn, err := net.FilePacketConn(s.file); err == nil {
+			packetConns = append(packetConns, packetConn)
+		} else {
+			return nil, nil, fmt.Errorf("systemd socket %s: %s", name, err)
+		}
+	}
+	if len(listeners) == 0 && len(packetConns) == 0 {
+		return nil, nil, fmt.Errorf("no systemd socket named %s, set FileDescriptorName=%s in the socket unit", name, name)
+	}
+	return listeners, packetConns, nil
+}
@@ -6,14 +6,17 @@ import (
 	"strconv"
 )
 
-type RelayAddressGeneratorNone struct{}
+type RelayAddressGeneratorNone struct {
+	// Address is the local ip relays are bound to, empty means all interfaces.
+	Address string
+}
 
 func (r *RelayAddressGeneratorNone) Validate() error {
 	return nil
 }
 
 func (r *RelayAddressGeneratorNone) AllocatePacketConn(network string, requestedPort int) (net.PacketConn, net.Addr, error) {
-	conn, err := net.ListenPacket("udp", ":"+strconv.Itoa(requestedPort))
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(r.Address, strconv.Itoa(requestedPort)))
 	if err != nil {
 		return nil, nil, err
 	}
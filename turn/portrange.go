@@ -2,8 +2,8 @@ package turn
 
 import (
 	"errors"
-	"fmt"
 	"net"
+	"strconv"
 
 	"github.com/pion/randutil"
 )
@@ -12,6 +12,8 @@ type RelayAddressGeneratorPortRange struct {
 	MinPort uint16
 	MaxPort uint16
 	Rand    randutil.MathRandomGenerator
+	// Address is the local ip relays are bound to, empty means all interfaces.
+	Address string
 }
 
 func (r *RelayAddressGeneratorPortRange) Validate() error {
@@ -24,7 +26,7 @@ func (r *RelayAddressGeneratorPortRange) Validate() error {
 
 func (r *RelayAddressGeneratorPortRange) AllocatePacketConn(network string, requestedPort int) (net.PacketConn, net.Addr, error) {
 	if requestedPort != 0 {
-		conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", requestedPort))
+		conn, err := net.ListenPacket("udp", net.JoinHostPort(r.Address, strconv.Itoa(requestedPort)))
 		if err != nil {
 			return nil, nil, err
 		}
@@ -34,7 +36,7 @@ func (r *RelayAddressGeneratorPortRange) AllocatePacketConn(network string, requ
 
 	for try := 0; try < 10; try++ {
 		port := r.MinPort + uint16(r.Rand.Intn(int((r.MaxPort+1)-r.MinPort)))
-		conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", port))
+		conn, err := net.ListenPacket("udp", net.JoinHostPort(r.Address, strconv.Itoa(int(port))))
 		if err != nil {
 			continue
 		}
@@ -0,0 +1,17 @@
+package turn
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	turnIPMismatchTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "screego_turn_ip_mismatch_total",
+		Help: "The total number of TURN authentications rejected because the client ip did not match",
+	})
+	turnCredentials = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "screego_turn_credentials",
+		Help: "The number of live credentials of the internal TURN server",
+	})
+)
@@ -3,37 +3,57 @@ package turn
 import (
 	"crypto/hmac"
 	"crypto/sha1"
+	"crypto/tls"
 	"encoding/base64"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/turn/v4"
 	"github.com/rs/zerolog/log"
 	"github.com/screego/server/config"
 	"github.com/screego/server/config/ipdns"
+	"github.com/screego/server/systemd"
 	"github.com/screego/server/util"
 )
 
 type Server interface {
-	Credentials(id string, addr net.IP) (string, string)
-	Disallow(username string)
+	// Credentials creates TURN credentials for the given id. The returned time is the point at which
+	// the credentials expire.
+	// peers are the addresses of the session participants, the TURN server may only allow relaying to them.
+	Credentials(id string, addr net.IP, peers []net.IP) (string, string, time.Time)
+	// Disallow revokes all credentials created for the given id.
+	Disallow(id string)
+	// Close stops the TURN listeners and closes all allocations.
+	Close() error
 }
 
 type InternalServer struct {
+	server *turn.Server
+	done   chan struct{}
 	lock   sync.RWMutex
+	ttl    time.Duration
 	lookup map[string]Entry
+	// clients maps the address of authenticated TURN clients to their username.
+	clients map[string]string
+	// bindClientIP only allows authentication from the ip the credentials were created for.
+	bindClientIP bool
 }
 
 type ExternalServer struct {
-	secret []byte
+	secret atomic.Pointer[[]byte]
 	ttl    time.Duration
 }
 
 type Entry struct {
+	id       string
 	addr     net.IP
+	peers    []net.IP
 	password []byte
+	expires  time.Time
 }
 
 const Realm = "screego"
@@ -66,98 +86,345 @@ func (r *Generator) AllocatePacketConn(network string, requestedPort int) (net.P
 	return conn, &relayAddr, err
 }
 
-func Start(conf config.Config) (Server, error) {
+// Start starts the internal TURN server or configures the external one. tlsConfig is used for the TURN TLS
+// listener, it may be nil if SCREEGO_TURN_TLS_ADDRESS isn't set.
+func Start(live *config.Live, tlsConfig *tls.Config) (Server, error) {
+	conf := live.Get()
 	if conf.TurnExternal {
 		return newExternalServer(conf)
 	} else {
-		return newInternalServer(conf)
+		return newInternalServer(live, tlsConfig)
 	}
 }
 
 func newExternalServer(conf config.Config) (Server, error) {
-	return &ExternalServer{
-		secret: []byte(conf.TurnExternalSecret),
-		ttl:    24 * time.Hour,
-	}, nil
+	svr := &ExternalServer{ttl: conf.TurnCredentialTTL}
+	svr.SetSecret(conf.TurnExternalSecret)
+	return svr, nil
 }
 
-func newInternalServer(conf config.Config) (Server, error) {
-	udpListener, err := net.ListenPacket("udp", conf.TurnAddress)
-	if err != nil {
-		return nil, fmt.Errorf("udp: could not listen on %s: %s", conf.TurnAddress, err)
-	}
-	tcpListener, err := net.Listen("tcp", conf.TurnAddress)
-	if err != nil {
-		return nil, fmt.Errorf("tcp: could not listen on %s: %s", conf.TurnAddress, err)
+func newInternalServer(live *config.Live, tlsConfig *tls.Config) (Server, error) {
+	conf := live.Get()
+	svr := &InternalServer{
+		done:         make(chan struct{}),
+		ttl:          conf.TurnCredentialTTL,
+		lookup:       map[string]Entry{},
+		clients:      map[string]string{},
+		bindClientIP: conf.TurnBindClientIP,
 	}
 
-	svr := &InternalServer{lookup: map[string]Entry{}}
+	relays := relays(conf)
 
-	gen := &Generator{
-		RelayAddressGenerator: generator(conf),
-		IPProvider:            conf.TurnIPProvider,
-	}
+	permissions := svr.permissions(live, relays)
 
-	var permissions turn.PermissionHandler = func(clientAddr net.Addr, peerIP net.IP) bool {
-		for _, cidr := range conf.TurnDenyPeersParsed {
-			if cidr.Contains(peerIP) {
-				return false
+	port, tlsPort := conf.TurnPort, conf.TurnTLSPort
+	var listenerConfigs []turn.ListenerConfig
+	var packetConnConfigs []turn.PacketConnConfig
+	for _, relay := range relays {
+		tcpListeners, udpListeners, err := listen(relay.address, true)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(relay.address, systemd.Prefix) {
+			if port, err = listenerPort(relay.address, tcpListeners, udpListeners); err != nil {
+				return nil, err
 			}
 		}
+		for _, tcpListener := range tcpListeners {
+			listenerConfigs = append(listenerConfigs,
+				turn.ListenerConfig{Listener: tcpListener, RelayAddressGenerator: relay.gen, PermissionHandler: permissions})
+		}
+		for _, udpListener := range udpListeners {
+			packetConnConfigs = append(packetConnConfigs,
+				turn.PacketConnConfig{PacketConn: udpListener, RelayAddressGenerator: relay.gen, PermissionHandler: permissions})
+		}
 
-		return true
+		if relay.tlsAddress != "" {
+			tcpListeners, _, err := listen(relay.tlsAddress, false)
+			if err != nil {
+				return nil, err
+			}
+			if strings.HasPrefix(relay.tlsAddress, systemd.Prefix) {
+				if tlsPort, err = listenerPort(relay.tlsAddress, tcpListeners, nil); err != nil {
+					return nil, err
+				}
+			}
+			for _, tcpListener := range tcpListeners {
+				listenerConfigs = append(listenerConfigs, turn.ListenerConfig{
+					Listener:              tls.NewListener(tcpListener, tlsConfig),
+					RelayAddressGenerator: relay.gen,
+					PermissionHandler:     permissions,
+				})
+			}
+		}
 	}
 
-	_, err = turn.NewServer(turn.ServerConfig{
+	server, err := turn.NewServer(turn.ServerConfig{
 		Realm:       Realm,
 		AuthHandler: svr.authenticate,
-		ListenerConfigs: []turn.ListenerConfig{
-			{Listener: tcpListener, RelayAddressGenerator: gen, PermissionHandler: permissions},
-		},
-		PacketConnConfigs: []turn.PacketConnConfig{
-			{PacketConn: udpListener, RelayAddressGenerator: gen, PermissionHandler: permissions},
+		EventHandler: turn.EventHandler{
+			OnAllocationDeleted: svr.allocationDeleted,
 		},
+		ListenerConfigs:   listenerConfigs,
+		PacketConnConfigs: packetConnConfigs,
 	})
 	if err != nil {
 		return nil, err
 	}
+	svr.server = server
 
-	log.Info().Str("addr", conf.TurnAddress).Msg("Start TURN/STUN")
+	if port != conf.TurnPort || tlsPort != conf.TurnTLSPort {
+		live.SetTurnPorts(port, tlsPort)
+	}
+
+	go svr.expirePeriodically(time.Minute)
+
+	for _, relay := range relays {
+		log.Info().Str("addr", relay.address).Msg("Start TURN/STUN")
+		if relay.tlsAddress != "" {
+			log.Info().Str("addr", relay.tlsAddress).Msg("Start TURN with tls")
+		}
+	}
 	return svr, nil
 }
 
-func generator(conf config.Config) turn.RelayAddressGenerator {
+// listen creates the tcp and optionally udp listeners of the address, either host:port or systemd:name for sockets
+// passed via systemd socket activation.
+func listen(address string, udp bool) ([]net.Listener, []net.PacketConn, error) {
+	if name, ok := strings.CutPrefix(address, systemd.Prefix); ok {
+		tcpListeners, udpListeners, err := systemd.Listen(name)
+		if err == nil && !udp && len(udpListeners) > 0 {
+			return nil, nil, fmt.Errorf("systemd socket %s must be a stream socket", name)
+		}
+		return tcpListeners, udpListeners, err
+	}
+
+	var udpListeners []net.PacketConn
+	if udp {
+		udpListener, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("udp: could not listen on %s: %s", address, err)
+		}
+		udpListeners = append(udpListeners, udpListener)
+	}
+	tcpListener, err := net.Listen("tcp", address)
+	if err != nil {
+		for _, udpListener := range udpListeners {
+			_ = udpListener.Close()
+		}
+		return nil, nil, fmt.Errorf("tcp: could not listen on %s: %s", address, err)
+	}
+	return []net.Listener{tcpListener}, udpListeners, nil
+}
+
+// listenerPort returns the port of the first listener.
+func listenerPort(address string, listeners []net.Listener, packetConns []net.PacketConn) (string, error) {
+	var addr net.Addr
+	if len(listeners) > 0 {
+		addr = listeners[0].Addr()
+	} else {
+		addr = packetConns[0].LocalAddr()
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "", fmt.Errorf("%s isn't a tcp or udp socket", address)
+	}
+	return port, nil
+}
+
+// relay is a TURN listener together with the generator for its relay addresses.
+type relay struct {
+	address    string
+	tlsAddress string
+	gen        *Generator
+}
+
+func relays(conf config.Config) []relay {
+	if len(conf.TurnRelayIPsParsed) == 0 {
+		return []relay{{
+			address:    conf.TurnAddress,
+			tlsAddress: conf.TurnTLSAddress,
+			gen:        &Generator{RelayAddressGenerator: generator(conf, ""), IPProvider: conf.TurnIPProvider},
+		}}
+	}
+
+	var result []relay
+	for _, relayIP := range conf.TurnRelayIPsParsed {
+		external := &ipdns.Static{}
+		if relayIP.External.To4() != nil {
+			external.V4 = relayIP.External
+		} else {
+			external.V6 = relayIP.External
+		}
+		log.Debug().Str("relay", relayIP.Relay.String()).Str("external", relayIP.External.String()).Msg("Using Relay IP")
+		tlsAddress := ""
+		if conf.TurnTLSPort != "" {
+			tlsAddress = net.JoinHostPort(relayIP.Relay.String(), conf.TurnTLSPort)
+		}
+		result = append(result, relay{
+			address:    net.JoinHostPort(relayIP.Relay.String(), conf.TurnPort),
+			tlsAddress: tlsAddress,
+			gen:        &Generator{RelayAddressGenerator: generator(conf, relayIP.Relay.String()), IPProvider: external},
+		})
+	}
+	return result
+}
+
+func generator(conf config.Config, address string) turn.RelayAddressGenerator {
 	min, max, useRange := conf.PortRange()
 	if useRange {
 		log.Debug().Uint16("min", min).Uint16("max", max).Msg("Using Port Range")
-		return &RelayAddressGeneratorPortRange{MinPort: min, MaxPort: max}
+		return &RelayAddressGeneratorPortRange{MinPort: min, MaxPort: max, Address: address}
+	}
+	return &RelayAddressGeneratorNone{Address: address}
+}
+
+func containsIP(cidrs []*net.IPNet, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissions decides if a client may relay to the peer. Denied peers are never allowed. With session peers only,
+// the relay addresses of this server are allowed regardless of the allowed peers, they are the peer when both users
+// of a session relay via TURN.
+func (a *InternalServer) permissions(live *config.Live, relays []relay) turn.PermissionHandler {
+	conf := live.Get()
+	return func(clientAddr net.Addr, peerIP net.IP) bool {
+		// the denied peers can be changed at runtime via config reload.
+		if containsIP(live.Get().TurnDenyPeersParsed, peerIP) {
+			return false
+		}
+
+		if conf.TurnSessionPeersOnly {
+			if isRelay(relays, peerIP) {
+				return true
+			}
+			if !a.isSessionPeer(clientAddr, peerIP) {
+				return false
+			}
+		}
+
+		return len(conf.TurnAllowPeersParsed) == 0 || containsIP(conf.TurnAllowPeersParsed, peerIP)
+	}
+}
+
+// isRelay checks if the ip is a relay address of this server. Relaying between two allocations is required
+// when both peers use TURN.
+func isRelay(relays []relay, ip net.IP) bool {
+	for _, relay := range relays {
+		v4, v6, err := relay.gen.IPProvider.Get()
+		if err == nil && (ip.Equal(v4) || ip.Equal(v6)) {
+			return true
+		}
 	}
-	return &RelayAddressGeneratorNone{}
+	return false
 }
 
-func (a *InternalServer) allow(username, password string, addr net.IP) {
+func (a *InternalServer) isSessionPeer(clientAddr net.Addr, peerIP net.IP) bool {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	entry, ok := a.lookup[a.clients[clientAddr.String()]]
+	if !ok {
+		return false
+	}
+
+	for _, peer := range entry.peers {
+		if peer.Equal(peerIP) {
+			return true
+		}
+	}
+	log.Debug().Str("addr", clientAddr.String()).Str("peer", peerIP.String()).Msg("TURN peer is not part of the session")
+	return false
+}
+
+func (a *InternalServer) allocationDeleted(srcAddr, dstAddr net.Addr, protocol, username, realm string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.clients, srcAddr.String())
+}
+
+func (a *InternalServer) allow(username, password, id string, addr net.IP, peers []net.IP, expires time.Time) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 	a.lookup[username] = Entry{
+		id:       id,
 		addr:     addr,
+		peers:    peers,
 		password: turn.GenerateAuthKey(username, Realm, password),
+		expires:  expires,
 	}
+	turnCredentials.Set(float64(len(a.lookup)))
 }
 
-func (a *InternalServer) Disallow(username string) {
+func (a *InternalServer) Disallow(id string) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
-	delete(a.lookup, username)
+	a.removeWhere(func(entry Entry) bool {
+		return entry.id == id
+	})
+}
+
+func (a *InternalServer) expirePeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.expire(time.Now())
+		case <-a.done:
+			return
+		}
+	}
+}
+
+func (a *InternalServer) expire(now time.Time) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.removeWhere(func(entry Entry) bool {
+		return entry.expires.Before(now)
+	})
+}
+
+// removeWhere must be called while holding the lock.
+func (a *InternalServer) removeWhere(remove func(entry Entry) bool) {
+	for username, entry := range a.lookup {
+		if remove(entry) {
+			delete(a.lookup, username)
+		}
+	}
+	for addr, username := range a.clients {
+		if _, ok := a.lookup[username]; !ok {
+			delete(a.clients, addr)
+		}
+	}
+	turnCredentials.Set(float64(len(a.lookup)))
+}
+
+func (a *InternalServer) Close() error {
+	close(a.done)
+	log.Info().Msg("Stop TURN/STUN")
+	return a.server.Close()
+}
+
+func (a *ExternalServer) Close() error {
+	return nil
 }
 
 func (a *ExternalServer) Disallow(username string) {
-	// not supported, will expire on TTL
+	// not supported, will expire on TTL. Active sessions receive new credentials before the old ones expire.
 }
 
 func (a *InternalServer) authenticate(username, realm string, addr net.Addr) ([]byte, bool) {
-	a.lock.RLock()
-	defer a.lock.RUnlock()
+	a.lock.Lock()
+	defer a.lock.Unlock()
 
 	entry, ok := a.lookup[username]
 
@@ -166,20 +433,54 @@ func (a *InternalServer) authenticate(username, realm string, addr net.Addr) ([]
 		return nil, false
 	}
 
+	if entry.expires.Before(time.Now()) {
+		log.Debug().Interface("addr", addr).Str("username", username).Msg("TURN credentials expired")
+		return nil, false
+	}
+
+	if a.bindClientIP && !entry.addr.Equal(addrIP(addr)) {
+		turnIPMismatchTotal.Inc()
+		log.Info().Str("addr", addr.String()).Str("expected", entry.addr.String()).Str("username", username).Msg("TURN client ip does not match")
+		return nil, false
+	}
+
+	a.clients[addr.String()] = username
 	log.Debug().Interface("addr", addr.String()).Str("realm", realm).Msg("TURN authenticated")
 	return entry.password, true
 }
 
-func (a *InternalServer) Credentials(id string, addr net.IP) (string, string) {
+func addrIP(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr.IP
+	case *net.TCPAddr:
+		return addr.IP
+	default:
+		return nil
+	}
+}
+
+func (a *InternalServer) Credentials(id string, addr net.IP, peers []net.IP) (string, string, time.Time) {
+	expires := time.Now().Add(a.ttl)
+	// the username must be unique, so that rotated credentials don't replace older ones which may still be in use
+	// until the client restarted ice.
+	username := fmt.Sprintf("%d:%s", expires.Unix(), id)
 	password := util.RandString(20)
-	a.allow(id, password, addr)
-	return id, password
+	a.allow(username, password, id, addr, peers, expires)
+	return username, password, expires
+}
+
+// SetSecret replaces the secret shared with the external TURN server, f.ex. after it was rotated.
+func (a *ExternalServer) SetSecret(secret string) {
+	value := []byte(secret)
+	a.secret.Store(&value)
 }
 
-func (a *ExternalServer) Credentials(id string, addr net.IP) (string, string) {
-	username := fmt.Sprintf("%d:%s", time.Now().Add(a.ttl).Unix(), id)
-	mac := hmac.New(sha1.New, a.secret)
+func (a *ExternalServer) Credentials(id string, addr net.IP, peers []net.IP) (string, string, time.Time) {
+	expires := time.Now().Add(a.ttl)
+	username := fmt.Sprintf("%d:%s", expires.Unix(), id)
+	mac := hmac.New(sha1.New, *a.secret.Load())
 	_, _ = mac.Write([]byte(username))
 	password := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-	return username, password
+	return username, password, expires
 }
@@ -0,0 +1,99 @@
+package turn
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/screego/server/config"
+	"github.com/screego/server/config/ipdns"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer() *InternalServer {
+	return &InternalServer{
+		ttl:     time.Minute,
+		lookup:  map[string]Entry{},
+		clients: map[string]string{},
+	}
+}
+
+func TestCredentials_expire(t *testing.T) {
+	svr := newTestServer()
+	addr := &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 5000}
+
+	username, _, expires := svr.Credentials("session", addr.IP, nil)
+	_, ok := svr.authenticate(username, Realm, addr)
+	assert.True(t, ok)
+
+	svr.expire(expires.Add(time.Second))
+	_, ok = svr.authenticate(username, Realm, addr)
+	assert.False(t, ok)
+	assert.Empty(t, svr.lookup)
+	assert.Empty(t, svr.clients)
+}
+
+func TestCredentials_disallowRemovesRotated(t *testing.T) {
+	svr := newTestServer()
+	ip := net.ParseIP("203.0.113.1")
+
+	first, _, _ := svr.Credentials("session", ip, nil)
+	svr.ttl = 2 * time.Minute
+	second, _, _ := svr.Credentials("session", ip, nil)
+	other, _, _ := svr.Credentials("other", ip, nil)
+	assert.NotEqual(t, first, second)
+	assert.Len(t, svr.lookup, 3)
+
+	svr.Disallow("session")
+	assert.Len(t, svr.lookup, 1)
+	assert.Contains(t, svr.lookup, other)
+}
+
+func TestAuthenticate_bindClientIP(t *testing.T) {
+	svr := newTestServer()
+	svr.bindClientIP = true
+
+	username, _, _ := svr.Credentials("session", net.ParseIP("203.0.113.1"), nil)
+
+	_, ok := svr.authenticate(username, Realm, &net.UDPAddr{IP: net.ParseIP("203.0.113.1"), Port: 5000})
+	assert.True(t, ok)
+	_, ok = svr.authenticate(username, Realm, &net.TCPAddr{IP: net.ParseIP("203.0.113.2"), Port: 5000})
+	assert.False(t, ok)
+}
+
+func TestIsSessionPeer(t *testing.T) {
+	svr := newTestServer()
+	host, client := net.ParseIP("203.0.113.1"), net.ParseIP("2001:db8::1")
+	addr := &net.UDPAddr{IP: host, Port: 5000}
+
+	username, _, _ := svr.Credentials("session", host, []net.IP{host, client})
+	assert.False(t, svr.isSessionPeer(addr, client))
+
+	_, ok := svr.authenticate(username, Realm, addr)
+	assert.True(t, ok)
+	assert.True(t, svr.isSessionPeer(addr, client))
+	assert.False(t, svr.isSessionPeer(addr, net.ParseIP("198.51.100.1")))
+}
+
+func TestPermissions_sessionPeersOnlyWithAllowedPeers(t *testing.T) {
+	svr := newTestServer()
+	host, client, other := net.ParseIP("198.51.100.1"), net.ParseIP("203.0.113.1"), net.ParseIP("198.51.100.2")
+	relayIP := net.ParseIP("192.0.2.1")
+	_, allowed, _ := net.ParseCIDR("198.51.100.0/24")
+	live := config.NewLive(config.Config{
+		TurnSessionPeersOnly: true,
+		TurnAllowPeersParsed: []*net.IPNet{allowed},
+	}, "")
+	relays := []relay{{gen: &Generator{IPProvider: &ipdns.Static{V4: relayIP}}}}
+	permissions := svr.permissions(live, relays)
+
+	addr := &net.UDPAddr{IP: host, Port: 5000}
+	username, _, _ := svr.Credentials("session", host, []net.IP{host, client})
+	_, ok := svr.authenticate(username, Realm, addr)
+	assert.True(t, ok)
+
+	assert.True(t, permissions(addr, relayIP), "relay to relay traffic isn't restricted by the allowed peers")
+	assert.True(t, permissions(addr, host))
+	assert.False(t, permissions(addr, client), "session peers must be allowed peers")
+	assert.False(t, permissions(addr, other), "allowed peers must be session peers")
+}
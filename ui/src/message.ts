@@ -43,6 +43,20 @@ export interface P2PSession {
     iceServers: ICEServer[];
 }
 
+export interface SessionRefresh {
+    id: string;
+    iceServers: ICEServer[];
+}
+
+export interface SessionStats {
+    sid: string;
+    candidateType: string;
+    rtt: number;
+    bitrate: number;
+    packetLoss: number;
+    frameRate: number;
+}
+
 export interface ICEServer {
     urls: string[];
     credential: string;
@@ -83,6 +97,9 @@ export type StopShare = Typed<{}, 'stopshare'>;
 export type RoomCreate = Typed<RoomConfiguration & {joinIfExist?: boolean}, 'create'>;
 export type JoinRoom = Typed<JoinConfiguration, 'join'>;
 export type EndShare = Typed<string, 'endshare'>;
+export type RefreshSession = Typed<SessionRefresh, 'refreshsession'>;
+export type Stats = Typed<SessionStats, 'stats'>;
+export type Restart = Typed<{drainSeconds: number}, 'restart'>;
 
 export type IncomingMessage =
     | Room
@@ -93,6 +110,8 @@ export type IncomingMessage =
     | ClientICECandidate
     | HostOffer
     | EndShare
+    | RefreshSession
+    | Restart
     | ClientAnswer;
 
 export type OutgoingMessage =
@@ -104,4 +123,5 @@ export type OutgoingMessage =
     | HostOffer
     | StopShare
     | ClientAnswer
-    | StartSharing;
+    | StartSharing
+    | Stats;
@@ -0,0 +1,109 @@
+import {SessionStats} from './message';
+
+export const statsInterval = 10_000;
+
+// The used subset of the RTCStats dictionaries, not all are part of the typescript dom types.
+interface Stat {
+    type: string;
+    timestamp: number;
+    kind?: string;
+    selectedCandidatePairId?: string;
+    nominated?: boolean;
+    state?: string;
+    localCandidateId?: string;
+    candidateType?: string;
+    currentRoundTripTime?: number;
+    bytesSent?: number;
+    bytesReceived?: number;
+    packetsReceived?: number;
+    packetsLost?: number;
+    fractionLost?: number;
+    framesPerSecond?: number;
+}
+
+export interface Counters {
+    bytes: number;
+    packets: number;
+    lost: number;
+    timestamp: number;
+}
+
+export interface CollectedStats {
+    // undefined on the first collection, the bitrate and packet loss require previous counters.
+    stats?: SessionStats;
+    counters: Counters;
+}
+
+export const collectStats = async (
+    sid: string,
+    peer: RTCPeerConnection,
+    previous?: Counters
+): Promise<CollectedStats | undefined> => {
+    const report = (await peer.getStats()) as unknown as Map<string, Stat>;
+
+    const found: {pair?: Stat; rtp?: Stat; remoteInbound?: Stat} = {};
+    report.forEach((stat) => {
+        switch (stat.type) {
+            case 'transport':
+                if (stat.selectedCandidatePairId) {
+                    found.pair = report.get(stat.selectedCandidatePairId);
+                }
+                return;
+            case 'candidate-pair':
+                // firefox doesn't expose the selected candidate pair via the transport stats
+                if (!found.pair && stat.nominated && stat.state === 'succeeded') {
+                    found.pair = stat;
+                }
+                return;
+            case 'inbound-rtp':
+            case 'outbound-rtp':
+                if (stat.kind === 'video') {
+                    found.rtp = stat;
+                }
+                return;
+            case 'remote-inbound-rtp':
+                if (stat.kind === 'video') {
+                    found.remoteInbound = stat;
+                }
+                return;
+        }
+    });
+
+    const {pair, rtp, remoteInbound} = found;
+    const local = pair?.localCandidateId ? report.get(pair.localCandidateId) : undefined;
+    if (!pair || !local?.candidateType) {
+        return undefined;
+    }
+
+    const counters: Counters = {
+        bytes: rtp?.bytesSent ?? rtp?.bytesReceived ?? 0,
+        packets: rtp?.packetsReceived ?? 0,
+        lost: rtp?.packetsLost ?? 0,
+        timestamp: rtp?.timestamp ?? pair.timestamp,
+    };
+    if (!previous || counters.timestamp <= previous.timestamp) {
+        return {counters};
+    }
+
+    const bitrate =
+        ((counters.bytes - previous.bytes) * 8 * 1000) / (counters.timestamp - previous.timestamp);
+
+    let packetLoss = remoteInbound?.fractionLost ?? 0;
+    const lost = counters.lost - previous.lost;
+    const total = counters.packets - previous.packets + lost;
+    if (rtp?.type === 'inbound-rtp' && total > 0) {
+        packetLoss = lost / total;
+    }
+
+    return {
+        counters,
+        stats: {
+            sid,
+            candidateType: local.candidateType,
+            rtt: pair.currentRoundTripTime ?? 0,
+            bitrate: Math.max(bitrate, 0),
+            packetLoss: Math.min(Math.max(packetLoss, 0), 1),
+            frameRate: rtp?.framesPerSecond ?? 0,
+        },
+    };
+};
@@ -11,6 +11,7 @@ import {
     UIConfig,
 } from './message';
 import {loadSettings, resolveCodecPlaceholder} from './settings';
+import {collectStats, Counters, statsInterval} from './stats';
 import {urlWithSlash} from './url';
 import {authModeToRoomMode} from './useConfig';
 import {getFromURL, useRoomID} from './useRoomID';
@@ -166,6 +167,7 @@ export const useRoom = (config: UIConfig): UseRoom => {
     const host = React.useRef<Record<string, RTCPeerConnection>>({});
     const client = React.useRef<Record<string, RTCPeerConnection>>({});
     const stream = React.useRef<MediaStream>(undefined);
+    const statCounters = React.useRef<Record<string, Counters>>({});
 
     const [state, setState] = React.useState<RoomState>(false);
 
@@ -279,6 +281,27 @@ export const useRoom = (config: UIConfig): UseRoom => {
                         case 'hostice':
                             client.current[event.payload.sid]?.addIceCandidate(event.payload.value);
                             return;
+                        case 'refreshsession':
+                            (async () => {
+                                const {id, iceServers} = event.payload;
+                                client.current[id]?.setConfiguration({...relayConfig, iceServers});
+                                const hostPeer = host.current[id];
+                                if (!hostPeer) {
+                                    return;
+                                }
+                                // the host restarts ice, so that both peers use the new credentials.
+                                hostPeer.setConfiguration({...relayConfig, iceServers});
+                                const offer = await hostPeer.createOffer({iceRestart: true});
+                                await hostPeer.setLocalDescription(offer);
+                                send({type: 'hostoffer', payload: {value: offer, sid: id}});
+                            })();
+                            return;
+                        case 'restart':
+                            enqueueSnackbar(
+                                `The server is restarting, the room will be closed in ${event.payload.drainSeconds} seconds.`,
+                                {variant: 'warning', persist: true}
+                            );
+                            return;
                         case 'endshare':
                             client.current[event.payload]?.close();
                             host.current[event.payload]?.close();
@@ -376,6 +399,29 @@ export const useRoom = (config: UIConfig): UseRoom => {
         conn.current?.send(JSON.stringify({type: 'name', payload: {username: name}}));
     };
 
+    React.useEffect(() => {
+        const interval = window.setInterval(async () => {
+            const peers = Object.entries({...host.current, ...client.current});
+            const next: Record<string, Counters> = {};
+            await Promise.all(
+                peers.map(async ([sid, peer]) => {
+                    const last = statCounters.current[sid];
+                    const collected = await collectStats(sid, peer, last).catch(() => undefined);
+                    if (!collected) {
+                        return;
+                    }
+                    const {stats, counters} = collected;
+                    next[sid] = counters;
+                    if (stats && conn.current?.readyState === WebSocket.OPEN) {
+                        conn.current.send(JSON.stringify({type: 'stats', payload: stats}));
+                    }
+                })
+            );
+            statCounters.current = next;
+        }, statsInterval);
+        return () => window.clearInterval(interval);
+    }, []);
+
     React.useEffect(() => {
         if (roomID) {
             const create = getFromURL('create') === 'true';
@@ -0,0 +1,18 @@
+package util
+
+import (
+	"net"
+	"net/http"
+)
+
+// RemoteIP returns the ip of the client. If trustProxy is enabled, the X-Real-IP header is used when present.
+func RemoteIP(r *http.Request, trustProxy bool) net.IP {
+	if realIP := r.Header.Get("X-Real-IP"); trustProxy && realIP != "" {
+		return net.ParseIP(realIP)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return net.ParseIP(r.RemoteAddr)
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,11 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var webhookDeliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "screego_webhook_deliveries_total",
+	Help: "The total number of webhook deliveries by result",
+}, []string{"result"})
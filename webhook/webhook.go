@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Event is the type of a webhook payload.
+type Event string
+
+const (
+	RoomCreated  Event = "room_created"
+	RoomClosed   Event = "room_closed"
+	UserJoined   Event = "user_joined"
+	UserLeft     Event = "user_left"
+	ShareStarted Event = "share_started"
+	ShareStopped Event = "share_stopped"
+	// ExternalIPChanged is sent when the external ip obtained via dns, http, stun or iface changed.
+	ExternalIPChanged Event = "external_ip_changed"
+)
+
+// Events contains all supported events.
+var Events = []Event{RoomCreated, RoomClosed, UserJoined, UserLeft, ShareStarted, ShareStopped, ExternalIPChanged}
+
+// Payload is sent as JSON to the webhook endpoints.
+type Payload struct {
+	Event   Event     `json:"event"`
+	Time    time.Time `json:"time"`
+	Room    string    `json:"room,omitempty"`
+	User    string    `json:"user,omitempty"`
+	Account string    `json:"account,omitempty"`
+	// V4 and V6 are the new ips of ExternalIPChanged.
+	V4 string `json:"v4,omitempty"`
+	V6 string `json:"v6,omitempty"`
+	// Text is a human-readable description, it makes the payload compatible with Slack and Mattermost incoming webhooks.
+	Text string `json:"text"`
+}
+
+const (
+	queueSize   = 100
+	maxAttempts = 5
+)
+
+// Dispatcher delivers payloads asynchronously to the configured endpoints. Each endpoint has its own queue,
+// so a slow endpoint doesn't delay the others.
+type Dispatcher struct {
+	endpoints []*endpoint
+	secret    []byte
+	events    map[Event]bool
+	client    *http.Client
+	// backoff is the delay before the first retry, it doubles with every attempt.
+	backoff time.Duration
+}
+
+type endpoint struct {
+	url   string
+	queue chan []byte
+}
+
+// New creates a dispatcher, it returns nil if no urls are configured. Sending to a nil dispatcher does nothing.
+// If events is empty all events are sent.
+func New(urls []string, secret string, events []string) *Dispatcher {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	d := &Dispatcher{
+		secret:  []byte(secret),
+		events:  map[Event]bool{},
+		client:  &http.Client{Timeout: 10 * time.Second},
+		backoff: time.Second,
+	}
+	for _, url := range urls {
+		d.endpoints = append(d.endpoints, &endpoint{url: url, queue: make(chan []byte, queueSize)})
+	}
+	for _, event := range events {
+		d.events[Event(event)] = true
+	}
+	return d
+}
+
+// Start starts the delivery workers.
+func (d *Dispatcher) Start() {
+	if d == nil {
+		return
+	}
+	for _, e := range d.endpoints {
+		go d.deliverAll(e)
+	}
+}
+
+// Send queues the payload for delivery. It never blocks, payloads are dropped when a queue is full.
+func (d *Dispatcher) Send(payload Payload) {
+	if d == nil || (len(d.events) > 0 && !d.events[payload.Event]) {
+		return
+	}
+
+	payload.Time = time.Now().UTC()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Err(err).Msg("Webhook")
+		return
+	}
+
+	for _, e := range d.endpoints {
+		select {
+		case e.queue <- body:
+		default:
+			webhookDeliveriesTotal.WithLabelValues("dropped").Inc()
+			log.Warn().Str("url", e.url).Str("event", string(payload.Event)).Msg("Webhook queue full, dropping payload")
+		}
+	}
+}
+
+func (d *Dispatcher) deliverAll(e *endpoint) {
+	for body := range e.queue {
+		d.deliver(e.url, body)
+	}
+}
+
+func (d *Dispatcher) deliver(url string, body []byte) {
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := d.post(url, body)
+		if err == nil {
+			webhookDeliveriesTotal.WithLabelValues("success").Inc()
+			return
+		}
+		if !retry || attempt == maxAttempts {
+			webhookDeliveriesTotal.WithLabelValues("failed").Inc()
+			log.Warn().Err(err).Str("url", url).Int("attempt", attempt).Msg("Webhook delivery failed")
+			return
+		}
+		log.Debug().Err(err).Str("url", url).Int("attempt", attempt).Msg("Webhook delivery failed, retrying")
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (d *Dispatcher) post(url string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(d.secret) > 0 {
+		req.Header.Set("X-Screego-Signature", "sha256="+Sign(d.secret, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_signsAndRetries(t *testing.T) {
+	received := make(chan Payload, 1)
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "sha256="+Sign([]byte("secret"), body), r.Header.Get("X-Screego-Signature"))
+
+		var payload Payload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	d := New([]string{server.URL}, "secret", nil)
+	d.backoff = time.Millisecond
+	d.Start()
+	d.Send(Payload{Event: RoomCreated, Room: "room", Text: "created"})
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, RoomCreated, payload.Event)
+		assert.Equal(t, "room", payload.Room)
+		assert.Equal(t, 2, attempts)
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
+
+func TestDispatcher_filtersEvents(t *testing.T) {
+	d := New([]string{"http://localhost"}, "", []string{string(ShareStarted)})
+	d.Send(Payload{Event: RoomCreated})
+	assert.Len(t, d.endpoints[0].queue, 0)
+	d.Send(Payload{Event: ShareStarted})
+	assert.Len(t, d.endpoints[0].queue, 1)
+}
+
+func TestDispatcher_nil(t *testing.T) {
+	var d *Dispatcher = New(nil, "", nil)
+	assert.Nil(t, d)
+	d.Start()
+	d.Send(Payload{Event: RoomCreated})
+}
@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -11,7 +12,10 @@ import (
 	"github.com/rs/xid"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/screego/server/util"
 	"github.com/screego/server/ws/outgoing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var ping = func(conn *websocket.Conn) error {
@@ -28,9 +32,12 @@ const (
 
 type Client struct {
 	conn *websocket.Conn
+	span trace.Span
 	info ClientInfo
 	once once
 	read chan<- ClientMessage
+	// done is closed when the main loop stopped reading messages.
+	done <-chan struct{}
 }
 
 type ClientMessage struct {
@@ -45,24 +52,40 @@ type ClientInfo struct {
 	AuthenticatedUser string
 	Write             chan outgoing.Message
 	Addr              net.IP
+	ctx               context.Context
 }
 
-func newClient(conn *websocket.Conn, req *http.Request, read chan ClientMessage, authenticatedUser string, authenticated, trustProxy bool) *Client {
-	ip := conn.RemoteAddr().(*net.TCPAddr).IP
-	if realIP := req.Header.Get("X-Real-IP"); trustProxy && realIP != "" {
-		ip = net.ParseIP(realIP)
+// Account returns the name of the logged-in user or an empty string for guests.
+func (c ClientInfo) Account() string {
+	if c.Authenticated {
+		return c.AuthenticatedUser
 	}
+	return ""
+}
+
+func newClient(conn *websocket.Conn, req *http.Request, read chan ClientMessage, done <-chan struct{}, authenticatedUser string, authenticated, trustProxy bool) *Client {
+	ip := util.RemoteIP(req, trustProxy)
+	id := xid.New()
+
+	ctx, span := tracer.Start(context.Background(), "websocket", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.String("connection", id.String()),
+		attribute.String("ip", ip.String()),
+		attribute.String("account", authenticatedUser),
+	))
 
 	client := &Client{
 		conn: conn,
+		span: span,
 		info: ClientInfo{
 			Authenticated:     authenticated,
 			AuthenticatedUser: authenticatedUser,
-			ID:                xid.New(),
+			ID:                id,
 			Addr:              ip,
 			Write:             make(chan outgoing.Message, 1),
+			ctx:               ctx,
 		},
 		read: read,
+		done: done,
 	}
 	client.debug().Msg("WebSocket New Connection")
 	return client
@@ -71,15 +94,10 @@ func newClient(conn *websocket.Conn, req *http.Request, read chan ClientMessage,
 // CloseOnError closes the connection.
 func (c *Client) CloseOnError(code int, reason string) {
 	c.once.Do(func() {
-		go func() {
-			c.read <- ClientMessage{
-				Info: c.info,
-				Incoming: &Disconnected{
-					Code:   code,
-					Reason: reason,
-				},
-			}
-		}()
+		go c.send(&Disconnected{
+			Code:   code,
+			Reason: reason,
+		})
 		c.writeCloseMessage(code, reason)
 	})
 }
@@ -123,7 +141,19 @@ func (c *Client) startReading(pongWait time.Duration) {
 			return
 		}
 		c.debug().Interface("event", fmt.Sprintf("%T", incoming)).Interface("payload", incoming).Msg("WebSocket Receive")
-		c.read <- ClientMessage{Info: c.info, Incoming: incoming}
+		if !c.send(incoming) {
+			return
+		}
+	}
+}
+
+// send passes the message to the main loop, it returns false if the main loop stopped.
+func (c *Client) send(incoming Event) bool {
+	select {
+	case c.read <- ClientMessage{Info: c.info, Incoming: incoming}:
+		return true
+	case <-c.done:
+		return false
 	}
 }
 
@@ -135,6 +165,8 @@ func (c *Client) startWriteHandler(pingPeriod time.Duration) {
 	pingTicker := time.NewTicker(pingPeriod)
 	defer pingTicker.Stop()
 	defer func() {
+		websocketConnections.Dec()
+		c.span.End()
 		c.debug().Msg("WebSocket Done")
 	}()
 	defer c.conn.Close()
@@ -142,6 +174,7 @@ func (c *Client) startWriteHandler(pingPeriod time.Duration) {
 		select {
 		case message := <-c.info.Write:
 			if msg, ok := message.(outgoing.CloseWriter); ok {
+				c.span.SetAttributes(attribute.Int("close.code", msg.Code), attribute.String("close.reason", msg.Reason))
 				c.debug().Str("reason", msg.Reason).Int("code", msg.Code).Msg("WebSocket Close")
 				c.CloseOnDone(msg.Code, msg.Reason)
 				return
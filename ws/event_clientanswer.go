@@ -32,6 +32,7 @@ func (e *ClientAnswer) Execute(rooms *Rooms, current ClientInfo) error {
 		return fmt.Errorf("permission denied for session %s", e.SID)
 	}
 
+	session.traceEvent("clientanswer")
 	room.Users[session.Host].WriteTimeout(outgoing.ClientAnswer(*e))
 
 	return nil
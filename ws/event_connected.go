@@ -4,5 +4,6 @@ type Connected struct{}
 
 func (e Connected) Execute(rooms *Rooms, current ClientInfo) error {
 	rooms.connected[current.ID] = ""
+	rooms.clients[current.ID] = current.Write
 	return nil
 }
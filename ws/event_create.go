@@ -3,9 +3,12 @@ package ws
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/rs/xid"
+	"github.com/screego/server/audit"
 	"github.com/screego/server/config"
+	"github.com/screego/server/webhook"
 )
 
 func init() {
@@ -44,7 +47,7 @@ func (e *Create) Execute(rooms *Rooms, current ClientInfo) error {
 		name = rooms.RandUserName()
 	}
 
-	switch rooms.config.AuthMode {
+	switch rooms.config().AuthMode {
 	case config.AuthModeNone:
 	case config.AuthModeAll:
 		if !current.Authenticated {
@@ -55,13 +58,14 @@ func (e *Create) Execute(rooms *Rooms, current ClientInfo) error {
 			return errors.New("you need to login")
 		}
 	default:
-		return errors.New("invalid authmode:" + rooms.config.AuthMode)
+		return errors.New("invalid authmode:" + rooms.config().AuthMode)
 	}
 
 	room := &Room{
 		ID:                e.ID,
 		CloseOnOwnerLeave: e.CloseOnOwnerLeave,
 		Mode:              e.Mode,
+		Created:           time.Now(),
 		Sessions:          map[xid.ID]*RoomSession{},
 		Users: map[xid.ID]*User{
 			current.ID: {
@@ -70,14 +74,19 @@ func (e *Create) Execute(rooms *Rooms, current ClientInfo) error {
 				Streaming: false,
 				Owner:     true,
 				Addr:      current.Addr,
+				Account:   current.Account(),
 				_write:    current.Write,
 			},
 		},
 	}
 	rooms.connected[current.ID] = room.ID
 	rooms.Rooms[e.ID] = room
+	room.audit(audit.RoomCreate, room.Users[current.ID])
+	rooms.notify(webhook.RoomCreated, room, room.Users[current.ID])
 	room.notifyInfoChanged()
 	usersJoinedTotal.Inc()
 	roomsCreatedTotal.Inc()
+	usersCurrent.WithLabelValues(modeLabel(room.Mode)).Inc()
+	roomsCurrent.WithLabelValues(modeLabel(room.Mode)).Inc()
 	return nil
 }
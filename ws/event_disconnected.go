@@ -4,6 +4,8 @@ import (
 	"bytes"
 
 	"github.com/gorilla/websocket"
+	"github.com/screego/server/audit"
+	"github.com/screego/server/webhook"
 	"github.com/screego/server/ws/outgoing"
 )
 
@@ -20,6 +22,8 @@ func (e *Disconnected) Execute(rooms *Rooms, current ClientInfo) error {
 func (e *Disconnected) executeNoError(rooms *Rooms, current ClientInfo) {
 	roomID := rooms.connected[current.ID]
 	delete(rooms.connected, current.ID)
+	delete(rooms.clients, current.ID)
+	websocketClosedTotal.WithLabelValues(closeReasonLabel(e.Reason)).Inc()
 	writeTimeout[outgoing.Message](current.Write, outgoing.CloseWriter{Code: e.Code, Reason: e.Reason})
 
 	if roomID == "" {
@@ -39,9 +43,6 @@ func (e *Disconnected) executeNoError(rooms *Rooms, current ClientInfo) {
 		return
 	}
 
-	delete(room.Users, current.ID)
-	usersLeftTotal.Inc()
-
 	for id, session := range room.Sessions {
 		if bytes.Equal(session.Client.Bytes(), current.ID.Bytes()) {
 			host, ok := room.Users[session.Host]
@@ -59,9 +60,21 @@ func (e *Disconnected) executeNoError(rooms *Rooms, current ClientInfo) {
 		}
 	}
 
+	// the user is removed after closing the sessions, so that they are still included in the audit log.
+	delete(room.Users, current.ID)
+	usersLeftTotal.Inc()
+	usersCurrent.WithLabelValues(modeLabel(room.Mode)).Dec()
+	if user.Streaming {
+		streamersCurrent.WithLabelValues(modeLabel(room.Mode)).Dec()
+	}
+	room.audit(audit.RoomLeave, user)
+	rooms.notify(webhook.UserLeft, room, user)
+
 	if user.Owner && room.CloseOnOwnerLeave {
 		for _, member := range room.Users {
 			delete(rooms.connected, member.ID)
+			delete(rooms.clients, member.ID)
+			websocketClosedTotal.WithLabelValues(CloseOwnerLeft).Inc()
 			member.WriteTimeout(outgoing.CloseWriter{Code: websocket.CloseNormalClosure, Reason: CloseOwnerLeft})
 		}
 		rooms.closeRoom(roomID)
@@ -0,0 +1,43 @@
+package ws
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/screego/server/webhook"
+)
+
+// ExternalIPChanged is sent by the server itself when the external ip changed. Active sessions receive new
+// ice servers with the new ip and the change is sent to the webhooks.
+type ExternalIPChanged struct {
+	V4 net.IP
+	V6 net.IP
+}
+
+func (e *ExternalIPChanged) Execute(rooms *Rooms, current ClientInfo) error {
+	for _, room := range rooms.Rooms {
+		if room.Mode == ConnectionLocal {
+			continue
+		}
+		for id, session := range room.Sessions {
+			log.Debug().Str("room", room.ID).Str("session", id.String()).Msg("Refresh ice servers after external ip change")
+			room.refreshSession(id, session, rooms, e.V4, e.V6)
+		}
+	}
+
+	payload := webhook.Payload{Event: webhook.ExternalIPChanged}
+	var ips []string
+	if e.V4 != nil {
+		payload.V4 = e.V4.String()
+		ips = append(ips, payload.V4)
+	}
+	if e.V6 != nil {
+		payload.V6 = e.V6.String()
+		ips = append(ips, payload.V6)
+	}
+	payload.Text = fmt.Sprintf("External ip changed to %s", strings.Join(ips, ", "))
+	rooms.webhooks.Send(payload)
+	return nil
+}
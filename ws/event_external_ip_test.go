@@ -0,0 +1,48 @@
+package ws
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/screego/server/config"
+	"github.com/screego/server/config/ipdns"
+	"github.com/screego/server/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalIPChanged_sendsWebhook(t *testing.T) {
+	received := make(chan webhook.Payload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhook.Payload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.NotEmpty(t, r.Header.Get("X-Screego-Signature"))
+		received <- payload
+	}))
+	defer server.Close()
+
+	rooms := NewRooms(nil, nil, config.NewLive(config.Config{
+		TurnIPProvider: &ipdns.Static{},
+		WebhookURLs:    []string{server.URL},
+		WebhookSecret:  "secret",
+	}, ""))
+	rooms.webhooks.Start()
+
+	event := &ExternalIPChanged{V4: net.ParseIP("192.0.2.1"), V6: net.ParseIP("2001:db8::1")}
+	require.NoError(t, event.Execute(rooms, ClientInfo{}))
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, webhook.ExternalIPChanged, payload.Event)
+		assert.Equal(t, "192.0.2.1", payload.V4)
+		assert.Equal(t, "2001:db8::1", payload.V6)
+		assert.Empty(t, payload.Room)
+		assert.Equal(t, "External ip changed to 192.0.2.1, 2001:db8::1", payload.Text)
+	case <-time.After(time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+}
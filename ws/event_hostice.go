@@ -32,6 +32,7 @@ func (e *HostICE) Execute(rooms *Rooms, current ClientInfo) error {
 		return fmt.Errorf("permission denied for session %s", e.SID)
 	}
 
+	session.traceEvent("hostice")
 	room.Users[session.Client].WriteTimeout(outgoing.HostICE(*e))
 
 	return nil
@@ -32,6 +32,7 @@ func (e *HostOffer) Execute(rooms *Rooms, current ClientInfo) error {
 		return fmt.Errorf("permission denied for session %s", e.SID)
 	}
 
+	session.traceEvent("hostoffer")
 	room.Users[session.Client].WriteTimeout(outgoing.HostOffer(*e))
 
 	return nil
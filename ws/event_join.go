@@ -2,6 +2,9 @@ package ws
 
 import (
 	"fmt"
+
+	"github.com/screego/server/audit"
+	"github.com/screego/server/webhook"
 )
 
 func init() {
@@ -38,13 +41,17 @@ func (e *Join) Execute(rooms *Rooms, current ClientInfo) error {
 		Streaming: false,
 		Owner:     false,
 		Addr:      current.Addr,
+		Account:   current.Account(),
 		_write:    current.Write,
 	}
 	rooms.connected[current.ID] = room.ID
+	room.audit(audit.RoomJoin, room.Users[current.ID])
+	rooms.notify(webhook.UserJoined, room, room.Users[current.ID])
 	room.notifyInfoChanged()
 	usersJoinedTotal.Inc()
+	usersCurrent.WithLabelValues(modeLabel(room.Mode)).Inc()
 
-	v4, v6, err := rooms.config.TurnIPProvider.Get()
+	v4, v6, err := rooms.config().TurnIPProvider.Get()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,34 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RefreshCredentials is sent periodically by the server itself. It renews TURN credentials of sessions which
+// will expire soon.
+type RefreshCredentials struct{}
+
+func (e *RefreshCredentials) Execute(rooms *Rooms, current ClientInfo) error {
+	v4, v6, err := rooms.config().TurnIPProvider.Get()
+	if err != nil {
+		// error is already logged by .Get()
+		return nil
+	}
+
+	refreshBefore := time.Now().Add(rooms.config().TurnCredentialTTL / 3)
+	for _, room := range rooms.Rooms {
+		if room.Mode != ConnectionTURN {
+			continue
+		}
+		for id, session := range room.Sessions {
+			if session.CredentialsExpire.IsZero() || session.CredentialsExpire.After(refreshBefore) {
+				continue
+			}
+			log.Debug().Str("room", room.ID).Str("session", id.String()).Msg("Refresh TURN credentials")
+			room.refreshSession(id, session, rooms, v4, v6)
+		}
+	}
+	return nil
+}
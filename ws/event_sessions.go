@@ -0,0 +1,60 @@
+package ws
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// SessionInfo describes an active session for the admin api.
+type SessionInfo struct {
+	ID          xid.ID         `json:"id"`
+	Room        string         `json:"room"`
+	Mode        ConnectionMode `json:"mode"`
+	Host        string         `json:"host"`
+	Client      string         `json:"client"`
+	Created     time.Time      `json:"created"`
+	HostStats   *PeerStats     `json:"hostStats"`
+	ClientStats *PeerStats     `json:"clientStats"`
+}
+
+// ListSessions is sent by the admin api.
+type ListSessions struct {
+	Response chan []SessionInfo
+}
+
+func (e *ListSessions) Execute(rooms *Rooms, current ClientInfo) error {
+	result := []SessionInfo{}
+	for _, room := range rooms.Rooms {
+		for id, session := range room.Sessions {
+			info := SessionInfo{
+				ID:      id,
+				Room:    room.ID,
+				Mode:    room.Mode,
+				Created: session.Created,
+			}
+			if host, ok := room.Users[session.Host]; ok {
+				info.Host = host.Name
+			}
+			if client, ok := room.Users[session.Client]; ok {
+				info.Client = client.Name
+			}
+			// the stats are copied, they are modified by the main loop.
+			if session.HostStats != nil {
+				stats := *session.HostStats
+				info.HostStats = &stats
+			}
+			if session.ClientStats != nil {
+				stats := *session.ClientStats
+				info.ClientStats = &stats
+			}
+			result = append(result, info)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Before(result[j].Created)
+	})
+	writeTimeout(e.Response, result)
+	return nil
+}
@@ -1,5 +1,10 @@
 package ws
 
+import (
+	"github.com/screego/server/audit"
+	"github.com/screego/server/webhook"
+)
+
 func init() {
 	register("share", func() Event {
 		return &StartShare{}
@@ -14,9 +19,14 @@ func (e *StartShare) Execute(rooms *Rooms, current ClientInfo) error {
 		return err
 	}
 
+	if !room.Users[current.ID].Streaming {
+		streamersCurrent.WithLabelValues(modeLabel(room.Mode)).Inc()
+	}
 	room.Users[current.ID].Streaming = true
+	room.audit(audit.ShareStart, room.Users[current.ID])
+	rooms.notify(webhook.ShareStarted, room, room.Users[current.ID])
 
-	v4, v6, err := rooms.config.TurnIPProvider.Get()
+	v4, v6, err := rooms.config().TurnIPProvider.Get()
 	if err != nil {
 		return err
 	}
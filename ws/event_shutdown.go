@@ -0,0 +1,41 @@
+package ws
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/screego/server/ws/outgoing"
+)
+
+// AnnounceShutdown is sent by the server itself when it shuts down.
+type AnnounceShutdown struct {
+	Drain time.Duration
+	// Empty is closed once all rooms are closed.
+	Empty chan struct{}
+}
+
+func (e *AnnounceShutdown) Execute(rooms *Rooms, current ClientInfo) error {
+	for _, write := range rooms.clients {
+		writeTimeout[outgoing.Message](write, outgoing.Restart{DrainSeconds: int(e.Drain.Seconds())})
+	}
+	rooms.empty = e.Empty
+	return nil
+}
+
+// Shutdown is sent by the server itself after the drain period. It closes all rooms and connections and stops
+// the main loop.
+type Shutdown struct{}
+
+func (e *Shutdown) Execute(rooms *Rooms, current ClientInfo) error {
+	for id := range rooms.Rooms {
+		rooms.closeRoom(id)
+	}
+	for id, write := range rooms.clients {
+		websocketClosedTotal.WithLabelValues(CloseShutdown).Inc()
+		writeTimeout[outgoing.Message](write, outgoing.CloseWriter{Code: websocket.CloseServiceRestart, Reason: CloseShutdown})
+		delete(rooms.clients, id)
+		delete(rooms.connected, id)
+	}
+	rooms.stopped = true
+	return nil
+}
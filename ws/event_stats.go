@@ -0,0 +1,78 @@
+package ws
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/rs/xid"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func init() {
+	register("stats", func() Event {
+		return &Stats{}
+	})
+}
+
+// Stats is reported periodically by both peers of a session.
+type Stats struct {
+	SID xid.ID `json:"sid"`
+	// CandidateType is the type of the local candidate of the selected candidate pair.
+	CandidateType string `json:"candidateType"`
+	// RTT is the round trip time in seconds.
+	RTT float64 `json:"rtt"`
+	// Bitrate is the sent or received video bitrate in bits per second.
+	Bitrate float64 `json:"bitrate"`
+	// PacketLoss is the fraction of lost packets between 0 and 1.
+	PacketLoss float64 `json:"packetLoss"`
+	FrameRate  float64 `json:"frameRate"`
+}
+
+var candidateTypes = []string{"host", "srflx", "prflx", "relay"}
+
+func (e *Stats) Execute(rooms *Rooms, current ClientInfo) error {
+	room, err := rooms.CurrentRoom(current)
+	if err != nil {
+		return err
+	}
+
+	session, ok := room.Sessions[e.SID]
+	if !ok {
+		log.Debug().Str("id", e.SID.String()).Msg("unknown session")
+		return nil
+	}
+
+	if !slices.Contains(candidateTypes, e.CandidateType) || e.RTT < 0 || e.Bitrate < 0 ||
+		e.PacketLoss < 0 || e.PacketLoss > 1 || e.FrameRate < 0 {
+		log.Debug().Str("id", e.SID.String()).Interface("stats", e).Msg("invalid stats")
+		return nil
+	}
+
+	before := session.candidateType()
+	switch current.ID {
+	case session.Host:
+		if session.HostStats == nil {
+			session.HostStats = &PeerStats{}
+		}
+		session.HostStats.add(e)
+	case session.Client:
+		if session.ClientStats == nil {
+			session.ClientStats = &PeerStats{}
+		}
+		session.ClientStats.add(e)
+	default:
+		return fmt.Errorf("permission denied for session %s", e.SID)
+	}
+
+	if after := session.candidateType(); before != after {
+		if before != "" {
+			sessionsByCandidateType.WithLabelValues(before).Dec()
+		}
+		sessionsByCandidateType.WithLabelValues(after).Inc()
+		if session.span != nil {
+			session.span.SetAttributes(attribute.String("candidate_type", after))
+		}
+	}
+	return nil
+}
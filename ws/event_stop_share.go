@@ -3,6 +3,8 @@ package ws
 import (
 	"bytes"
 
+	"github.com/screego/server/audit"
+	"github.com/screego/server/webhook"
 	"github.com/screego/server/ws/outgoing"
 )
 
@@ -20,7 +22,12 @@ func (e *StopShare) Execute(rooms *Rooms, current ClientInfo) error {
 		return err
 	}
 
+	if room.Users[current.ID].Streaming {
+		streamersCurrent.WithLabelValues(modeLabel(room.Mode)).Dec()
+	}
 	room.Users[current.ID].Streaming = false
+	room.audit(audit.ShareStop, room.Users[current.ID])
+	rooms.notify(webhook.ShareStopped, room, room.Users[current.ID])
 	for id, session := range room.Sessions {
 		if bytes.Equal(session.Host.Bytes(), current.ID.Bytes()) {
 			client, ok := room.Users[session.Client]
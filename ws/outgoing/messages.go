@@ -48,6 +48,15 @@ func (ClientSession) Type() string {
 	return "clientsession"
 }
 
+type RefreshSession struct {
+	ID         xid.ID      `json:"id"`
+	ICEServers []ICEServer `json:"iceServers"`
+}
+
+func (RefreshSession) Type() string {
+	return "refreshsession"
+}
+
 type ICEServer struct {
 	URLs       []string `json:"urls"`
 	Credential string   `json:"credential"`
@@ -89,6 +98,15 @@ func (EndShare) Type() string {
 	return "endshare"
 }
 
+// Restart announces that the server shuts down and closes all connections after the drain period.
+type Restart struct {
+	DrainSeconds int `json:"drainSeconds"`
+}
+
+func (Restart) Type() string {
+	return "restart"
+}
+
 type ConnectionMode string
 
 const (
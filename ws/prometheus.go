@@ -1,6 +1,9 @@
 package ws
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -30,4 +33,124 @@ var (
 		Name: "screego_session_closed_total",
 		Help: "The total number of sessions closed",
 	})
+
+	roomsCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "screego_rooms",
+		Help: "The number of open rooms",
+	}, []string{"mode"})
+	usersCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "screego_users",
+		Help: "The number of users in rooms",
+	}, []string{"mode"})
+	streamersCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "screego_streamers",
+		Help: "The number of users sharing their screen",
+	}, []string{"mode"})
+	sessionsCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "screego_sessions",
+		Help: "The number of active sessions",
+	}, []string{"mode"})
+	websocketConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "screego_websocket_connections",
+		Help: "The number of open websocket connections",
+	})
+
+	roomLifetime = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "screego_room_lifetime_seconds",
+		Help:    "How long rooms were open",
+		Buckets: prometheus.ExponentialBuckets(60, 2, 10),
+	}, []string{"mode"})
+	sessionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "screego_session_duration_seconds",
+		Help:    "How long sessions were active",
+		Buckets: prometheus.ExponentialBuckets(10, 2, 12),
+	}, []string{"mode"})
+	eventDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "screego_event_duration_seconds",
+		Help:    "How long processing an event in the main loop took",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 8),
+	}, []string{"event"})
+
+	eventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "screego_events_total",
+		Help: "The total number of processed events",
+	}, []string{"event"})
+	eventErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "screego_event_errors_total",
+		Help: "The total number of events that failed and disconnected the client",
+	}, []string{"event"})
+	sessionsByCandidateType = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "screego_sessions_by_candidate_type",
+		Help: "The number of active sessions by the candidate type reported by the clients, relay if at least one peer uses TURN",
+	}, []string{"candidate_type"})
+	sessionCandidateTypeTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "screego_session_candidate_type_total",
+		Help: "The total number of closed sessions by the candidate type reported by the clients",
+	}, []string{"candidate_type"})
+	sessionRTT = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "screego_session_rtt_seconds",
+		Help:    "The average round trip time of sessions reported by the clients",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 10),
+	}, []string{"role"})
+	sessionBitrate = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "screego_session_bitrate_bits_per_second",
+		Help:    "The average video bitrate of sessions reported by the clients",
+		Buckets: prometheus.ExponentialBuckets(125_000, 2, 10),
+	}, []string{"role"})
+	sessionPacketLoss = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "screego_session_packet_loss_ratio",
+		Help:    "The average packet loss of sessions reported by the clients",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2},
+	}, []string{"role"})
+	sessionFrameRate = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "screego_session_frame_rate",
+		Help:    "The average video frame rate of sessions reported by the clients",
+		Buckets: []float64{1, 5, 10, 15, 20, 25, 30, 45, 60},
+	}, []string{"role"})
+
+	websocketClosedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "screego_websocket_closed_total",
+		Help: "The total number of closed websocket connections by reason",
+	}, []string{"reason"})
 )
+
+// closeReasons are the known prefixes of disconnect reasons, the remaining part contains error details
+// which would result in unbounded label values.
+var closeReasons = []string{
+	CloseOwnerLeft,
+	"Reader Routine Closed",
+	"read error",
+	"unsupported binary message type",
+	"malformed message",
+	"malformed outgoing",
+	"write error",
+	"ping timeout",
+}
+
+func closeReasonLabel(reason string) string {
+	for _, known := range closeReasons {
+		if strings.HasPrefix(reason, known) {
+			return known
+		}
+	}
+	return "event error"
+}
+
+// eventName returns the lowercase type name of the event, f.ex. "join".
+func eventName(e Event) string {
+	t := reflect.TypeOf(e)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return strings.ToLower(t.Name())
+}
+
+// modeLabel returns the mode as label, the mode is sent by clients and unknown modes would create arbitrary labels.
+func modeLabel(mode ConnectionMode) string {
+	switch mode {
+	case ConnectionLocal, ConnectionSTUN, ConnectionTURN:
+		return string(mode)
+	default:
+		return "other"
+	}
+}
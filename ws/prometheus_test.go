@@ -0,0 +1,24 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseReasonLabel(t *testing.T) {
+	assert.Equal(t, "read error", closeReasonLabel("read error: websocket: close 1001 (going away)"))
+	assert.Equal(t, "malformed message", closeReasonLabel("malformed message: invalid character"))
+	assert.Equal(t, CloseOwnerLeft, closeReasonLabel(CloseOwnerLeft))
+	assert.Equal(t, "event error", closeReasonLabel("room with id abc does not exist"))
+}
+
+func TestModeLabel(t *testing.T) {
+	assert.Equal(t, "turn", modeLabel(ConnectionTURN))
+	assert.Equal(t, "other", modeLabel("unknown"))
+}
+
+func TestEventName(t *testing.T) {
+	assert.Equal(t, "join", eventName(&Join{}))
+	assert.Equal(t, "connected", eventName(Connected{}))
+}
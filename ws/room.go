@@ -8,8 +8,10 @@ import (
 
 	"github.com/rs/xid"
 	"github.com/rs/zerolog/log"
+	"github.com/screego/server/audit"
 	"github.com/screego/server/config"
 	"github.com/screego/server/ws/outgoing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ConnectionMode string
@@ -24,6 +26,7 @@ type Room struct {
 	ID                string
 	CloseOnOwnerLeave bool
 	Mode              ConnectionMode
+	Created           time.Time
 	Users             map[xid.ID]*User
 	Sessions          map[xid.ID]*RoomSession
 }
@@ -31,16 +34,36 @@ type Room struct {
 const (
 	CloseOwnerLeft = "Owner Left"
 	CloseDone      = "Read End"
+	CloseShutdown  = "Server Restart"
 )
 
 func (r *Room) newSession(host, client xid.ID, rooms *Rooms, v4, v6 net.IP) {
 	id := xid.New()
-	r.Sessions[id] = &RoomSession{
-		Host:   host,
-		Client: client,
+	session := &RoomSession{
+		Host:    host,
+		Client:  client,
+		Created: time.Now(),
 	}
+	r.Sessions[id] = session
 	sessionCreatedTotal.Inc()
+	sessionsCurrent.WithLabelValues(modeLabel(r.Mode)).Inc()
+	r.auditSession(audit.SessionCreate, id, session)
+	r.startSessionSpan(id, session)
 
+	iceHost, iceClient := r.iceServers(id, session, rooms, v4, v6)
+	r.Users[host].WriteTimeout(outgoing.HostSession{Peer: client, ID: id, ICEServers: iceHost})
+	r.Users[client].WriteTimeout(outgoing.ClientSession{Peer: host, ID: id, ICEServers: iceClient})
+}
+
+// refreshSession sends new ice servers with fresh credentials to both peers of the session.
+func (r *Room) refreshSession(id xid.ID, session *RoomSession, rooms *Rooms, v4, v6 net.IP) {
+	session.traceEvent("refresh")
+	iceHost, iceClient := r.iceServers(id, session, rooms, v4, v6)
+	r.Users[session.Host].WriteTimeout(outgoing.RefreshSession{ID: id, ICEServers: iceHost})
+	r.Users[session.Client].WriteTimeout(outgoing.RefreshSession{ID: id, ICEServers: iceClient})
+}
+
+func (r *Room) iceServers(id xid.ID, session *RoomSession, rooms *Rooms, v4, v6 net.IP) ([]outgoing.ICEServer, []outgoing.ICEServer) {
 	iceHost := []outgoing.ICEServer{}
 	iceClient := []outgoing.ICEServer{}
 	switch r.Mode {
@@ -49,8 +72,11 @@ func (r *Room) newSession(host, client xid.ID, rooms *Rooms, v4, v6 net.IP) {
 		iceHost = []outgoing.ICEServer{{URLs: rooms.addresses("stun", v4, v6, false)}}
 		iceClient = []outgoing.ICEServer{{URLs: rooms.addresses("stun", v4, v6, false)}}
 	case ConnectionTURN:
-		hostName, hostPW := rooms.turnServer.Credentials(id.String()+"host", r.Users[host].Addr)
-		clientName, clientPW := rooms.turnServer.Credentials(id.String()+"client", r.Users[client].Addr)
+		hostAddr, clientAddr := r.Users[session.Host].Addr, r.Users[session.Client].Addr
+		peers := []net.IP{hostAddr, clientAddr}
+		hostName, hostPW, expires := rooms.turnServer.Credentials(id.String()+"host", hostAddr, peers)
+		clientName, clientPW, _ := rooms.turnServer.Credentials(id.String()+"client", clientAddr, peers)
+		session.CredentialsExpire = expires
 		iceHost = []outgoing.ICEServer{{
 			URLs:       rooms.addresses("turn", v4, v6, true),
 			Credential: hostPW,
@@ -62,27 +88,47 @@ func (r *Room) newSession(host, client xid.ID, rooms *Rooms, v4, v6 net.IP) {
 			Username:   clientName,
 		}}
 	}
-	r.Users[host].WriteTimeout(outgoing.HostSession{Peer: client, ID: id, ICEServers: iceHost})
-	r.Users[client].WriteTimeout(outgoing.ClientSession{Peer: host, ID: id, ICEServers: iceClient})
+	return iceHost, iceClient
 }
 
 func (r *Rooms) addresses(prefix string, v4, v6 net.IP, tcp bool) (result []string) {
-	if v4 != nil {
-		result = append(result, fmt.Sprintf("%s:%s:%s", prefix, v4.String(), r.config.TurnPort))
-		if tcp {
-			result = append(result, fmt.Sprintf("%s:%s:%s?transport=tcp", prefix, v4.String(), r.config.TurnPort))
+	conf := r.config()
+	ips := []net.IP{v4, v6}
+	if len(conf.TurnRelayIPsParsed) > 0 {
+		// the TURN server only listens on the relay ips, clients must use their external ips.
+		ips = nil
+		for _, relayIP := range conf.TurnRelayIPsParsed {
+			ips = append(ips, relayIP.External)
 		}
 	}
-	if v6 != nil {
-		result = append(result, fmt.Sprintf("%s:[%s]:%s", prefix, v6.String(), r.config.TurnPort))
+
+	for _, ip := range ips {
+		if ip == nil {
+			continue
+		}
+		host := net.JoinHostPort(ip.String(), conf.TurnPort)
+		result = append(result, fmt.Sprintf("%s:%s", prefix, host))
 		if tcp {
-			result = append(result, fmt.Sprintf("%s:[%s]:%s?transport=tcp", prefix, v6.String(), r.config.TurnPort))
+			result = append(result, fmt.Sprintf("%s:%s?transport=tcp", prefix, host))
 		}
 	}
+	if prefix == "turn" && tcp && conf.TurnTLSPort != "" {
+		// the certificate is only valid for the domain, not the ip addresses.
+		result = append(result, fmt.Sprintf("turns:%s:%s?transport=tcp", conf.TurnTLSDomain, conf.TurnTLSPort))
+	}
 	return
 }
 
 func (r *Room) closeSession(rooms *Rooms, id xid.ID) {
+	if session, ok := r.Sessions[id]; ok {
+		r.auditSession(audit.SessionClose, id, session)
+		sessionsCurrent.WithLabelValues(modeLabel(r.Mode)).Dec()
+		sessionDuration.WithLabelValues(modeLabel(r.Mode)).Observe(time.Since(session.Created).Seconds())
+		session.observeStats()
+		if session.span != nil {
+			session.span.End()
+		}
+	}
 	if r.Mode == ConnectionTURN {
 		rooms.turnServer.Disallow(id.String() + "host")
 		rooms.turnServer.Disallow(id.String() + "client")
@@ -92,8 +138,13 @@ func (r *Room) closeSession(rooms *Rooms, id xid.ID) {
 }
 
 type RoomSession struct {
-	Host   xid.ID
-	Client xid.ID
+	Host              xid.ID
+	Client            xid.ID
+	Created           time.Time
+	CredentialsExpire time.Time
+	HostStats         *PeerStats
+	ClientStats       *PeerStats
+	span              trace.Span
 }
 
 func (r *Room) notifyInfoChanged() {
@@ -131,15 +182,38 @@ func (r *Room) notifyInfoChanged() {
 	}
 }
 
+func (r *Room) audit(event audit.Event, user *User) {
+	audit.Log(audit.Entry{Event: event, User: user.actor(), Room: r.ID})
+}
+
+func (r *Room) auditSession(event audit.Event, id xid.ID, session *RoomSession) {
+	streamer := r.Users[session.Host].actor()
+	audit.Log(audit.Entry{
+		Event:    event,
+		User:     r.Users[session.Client].actor(),
+		Room:     r.ID,
+		Session:  id.String(),
+		Streamer: &streamer,
+	})
+}
+
 type User struct {
 	ID        xid.ID
 	Addr      net.IP
 	Name      string
+	Account   string
 	Streaming bool
 	Owner     bool
 	_write    chan<- outgoing.Message
 }
 
+func (u *User) actor() audit.Actor {
+	if u == nil {
+		return audit.Actor{}
+	}
+	return audit.Actor{Name: u.Name, Account: u.Account, IP: u.Addr.String()}
+}
+
 func (u *User) WriteTimeout(msg outgoing.Message) {
 	writeTimeout(u._write, msg)
 }
@@ -0,0 +1,42 @@
+package ws
+
+import (
+	"net"
+	"testing"
+
+	"github.com/screego/server/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddresses_turnTLS(t *testing.T) {
+	rooms := &Rooms{live: config.NewLive(config.Config{
+		TurnPort:      "3478",
+		TurnTLSDomain: "turn.example.org",
+		TurnTLSPort:   "5349",
+	}, "")}
+
+	v4 := net.ParseIP("203.0.113.5")
+	assert.Equal(t, []string{
+		"turn:203.0.113.5:3478",
+		"turn:203.0.113.5:3478?transport=tcp",
+		"turns:turn.example.org:5349?transport=tcp",
+	}, rooms.addresses("turn", v4, nil, true))
+	assert.Equal(t, []string{"stun:203.0.113.5:3478"}, rooms.addresses("stun", v4, nil, false))
+}
+
+func TestAddresses_relayIPs(t *testing.T) {
+	rooms := &Rooms{live: config.NewLive(config.Config{
+		TurnPort: "3478",
+		TurnRelayIPsParsed: []config.RelayIP{
+			{Relay: net.ParseIP("10.0.0.5"), External: net.ParseIP("203.0.113.5")},
+			{Relay: net.ParseIP("2001:db8::5"), External: net.ParseIP("2001:db8::5")},
+		},
+	}, "")}
+
+	assert.Equal(t, []string{
+		"turn:203.0.113.5:3478",
+		"turn:203.0.113.5:3478?transport=tcp",
+		"turn:[2001:db8::5]:3478",
+		"turn:[2001:db8::5]:3478?transport=tcp",
+	}, rooms.addresses("turn", net.ParseIP("198.51.100.1"), nil, true))
+}
@@ -1,29 +1,42 @@
 package ws
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/xid"
 	"github.com/rs/zerolog/log"
+	"github.com/screego/server/audit"
 	"github.com/screego/server/auth"
 	"github.com/screego/server/config"
 	"github.com/screego/server/turn"
 	"github.com/screego/server/util"
+	"github.com/screego/server/webhook"
+	"github.com/screego/server/ws/outgoing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewRooms(tServer turn.Server, users *auth.Users, conf config.Config) *Rooms {
+func NewRooms(tServer turn.Server, users *auth.Users, live *config.Live) *Rooms {
+	conf := live.Get()
 	return &Rooms{
 		Rooms:      map[string]*Room{},
 		Incoming:   make(chan ClientMessage),
 		connected:  map[xid.ID]string{},
+		clients:    map[xid.ID]chan<- outgoing.Message{},
+		done:       make(chan struct{}),
 		turnServer: tServer,
 		users:      users,
-		config:     conf,
+		live:       live,
+		webhooks:   webhook.New(conf.WebhookURLs, conf.WebhookSecret, conf.WebhookEvents),
 		r:          rand.New(rand.NewSource(time.Now().Unix())),
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
@@ -37,7 +50,7 @@ func NewRooms(tServer turn.Server, users *auth.Users, conf config.Config) *Rooms
 				if u.Host == r.Host {
 					return true
 				}
-				return conf.CheckOrigin(origin)
+				return live.Get().CheckOrigin(origin)
 			},
 		},
 	}
@@ -49,9 +62,26 @@ type Rooms struct {
 	Incoming   chan ClientMessage
 	upgrader   websocket.Upgrader
 	users      *auth.Users
-	config     config.Config
+	live       *config.Live
+	webhooks   *webhook.Dispatcher
 	r          *rand.Rand
 	connected  map[xid.ID]string
+	// clients contains the write channel of each connected client, it's used to close all connections on shutdown.
+	clients     map[xid.ID]chan<- outgoing.Message
+	connections sync.WaitGroup
+	// connectionsLock ensures no connection is added after the shutdown started.
+	connectionsLock sync.Mutex
+	draining        atomic.Bool
+	stopped         bool
+	// done is closed when the main loop stopped, senders to Incoming must select on it.
+	done chan struct{}
+	// empty is closed once all rooms are closed while draining, it's nil otherwise.
+	empty chan struct{}
+}
+
+// config returns the current config, some settings can change at runtime.
+func (r *Rooms) config() config.Config {
+	return r.live.Get()
 }
 
 func (r *Rooms) CurrentRoom(info ClientInfo) (*Room, error) {
@@ -79,8 +109,15 @@ func (r *Rooms) RandRoomName() string {
 }
 
 func (r *Rooms) Upgrade(w http.ResponseWriter, req *http.Request) {
+	if !r.addConnection() {
+		w.WriteHeader(503)
+		_, _ = fmt.Fprint(w, "Server is shutting down")
+		return
+	}
+
 	conn, err := r.upgrader.Upgrade(w, req, nil)
 	if err != nil {
+		r.connections.Done()
 		log.Debug().Err(err).Msg("Websocket upgrade")
 		w.WriteHeader(400)
 		_, _ = fmt.Fprintf(w, "Upgrade failed %s", err)
@@ -88,28 +125,135 @@ func (r *Rooms) Upgrade(w http.ResponseWriter, req *http.Request) {
 	}
 
 	user, loggedIn := r.users.CurrentUser(req)
-	c := newClient(conn, req, r.Incoming, user, loggedIn, r.config.TrustProxyHeaders)
-	r.Incoming <- ClientMessage{Info: c.info, Incoming: Connected{}, SkipConnectedCheck: true}
+	c := newClient(conn, req, r.Incoming, r.done, user, loggedIn, r.config().TrustProxyHeaders)
+	select {
+	case r.Incoming <- ClientMessage{Info: c.info, Incoming: Connected{}, SkipConnectedCheck: true}:
+	case <-r.done:
+		c.CloseOnDone(websocket.CloseServiceRestart, CloseShutdown)
+		c.span.End()
+		r.connections.Done()
+		return
+	}
 
+	websocketConnections.Inc()
 	go c.startReading(time.Second * 20)
-	go c.startWriteHandler(time.Second * 5)
+	go func() {
+		defer r.connections.Done()
+		c.startWriteHandler(time.Second * 5)
+	}()
+}
+
+// addConnection registers a new connection, it returns false once the shutdown started.
+func (r *Rooms) addConnection() bool {
+	r.connectionsLock.Lock()
+	defer r.connectionsLock.Unlock()
+	if r.draining.Load() {
+		return false
+	}
+	r.connections.Add(1)
+	return true
 }
 
 func (r *Rooms) Start() {
+	r.webhooks.Start()
+	r.config().TurnIPProvider.OnChange(func(v4, v6 net.IP) {
+		// the change may be detected inside the main loop, therefore the message must be sent asynchronously.
+		go func() {
+			select {
+			case r.Incoming <- ClientMessage{SkipConnectedCheck: true, Incoming: &ExternalIPChanged{V4: v4, V6: v6}}:
+			case <-r.done:
+			}
+		}()
+	})
+	go r.refreshCredentialsPeriodically(refreshInterval(r.config().TurnCredentialTTL))
+
 	for msg := range r.Incoming {
-		_, connected := r.connected[msg.Info.ID]
-		if !msg.SkipConnectedCheck && !connected {
-			log.Debug().Interface("event", fmt.Sprintf("%T", msg.Incoming)).Interface("payload", msg.Incoming).Msg("WebSocket Ignore")
-			continue
+		r.process(msg)
+		if r.empty != nil && len(r.Rooms) == 0 {
+			close(r.empty)
+			r.empty = nil
 		}
+		if r.stopped {
+			close(r.done)
+			log.Debug().Msg("Main loop stopped")
+			return
+		}
+	}
+}
 
-		if err := msg.Incoming.Execute(r, msg.Info); err != nil {
-			dis := Disconnected{Code: websocket.CloseNormalClosure, Reason: err.Error()}
-			dis.executeNoError(r, msg.Info)
+func (r *Rooms) process(msg ClientMessage) {
+	_, connected := r.connected[msg.Info.ID]
+	if !msg.SkipConnectedCheck && !connected {
+		log.Debug().Interface("event", fmt.Sprintf("%T", msg.Incoming)).Interface("payload", msg.Incoming).Msg("WebSocket Ignore")
+		return
+	}
+
+	name := eventName(msg.Incoming)
+	eventsTotal.WithLabelValues(name).Inc()
+	start := time.Now()
+	_, span := tracer.Start(msg.Info.traceContext(), "event "+name, trace.WithAttributes(r.traceAttributes(msg.Info)...))
+	if err := msg.Incoming.Execute(r, msg.Info); err != nil {
+		eventErrorsTotal.WithLabelValues(name).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		dis := Disconnected{Code: websocket.CloseNormalClosure, Reason: err.Error()}
+		dis.executeNoError(r, msg.Info)
+	}
+	span.End()
+	eventDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+}
+
+// notify sends a webhook for the room event, user may be nil.
+func (r *Rooms) notify(event webhook.Event, room *Room, user *User) {
+	payload := webhook.Payload{Event: event, Room: room.ID}
+	name := "Someone"
+	if user != nil {
+		payload.User = user.Name
+		payload.Account = user.Account
+		name = user.Name
+	}
+
+	switch event {
+	case webhook.RoomCreated:
+		payload.Text = fmt.Sprintf("%s created room %s", name, room.ID)
+	case webhook.RoomClosed:
+		payload.Text = fmt.Sprintf("Room %s was closed", room.ID)
+	case webhook.UserJoined:
+		payload.Text = fmt.Sprintf("%s joined room %s", name, room.ID)
+	case webhook.UserLeft:
+		payload.Text = fmt.Sprintf("%s left room %s", name, room.ID)
+	case webhook.ShareStarted:
+		payload.Text = fmt.Sprintf("%s started sharing in room %s", name, room.ID)
+	case webhook.ShareStopped:
+		payload.Text = fmt.Sprintf("%s stopped sharing in room %s", name, room.ID)
+	}
+	r.webhooks.Send(payload)
+}
+
+func (r *Rooms) refreshCredentialsPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.done:
+			return
+		}
+		select {
+		case r.Incoming <- ClientMessage{SkipConnectedCheck: true, Incoming: &RefreshCredentials{}}:
+		case <-r.done:
+			return
 		}
 	}
 }
 
+// refreshInterval returns how often sessions are checked for expiring credentials.
+// Credentials are renewed once less than a third of the ttl is left, checking ten times per ttl
+// ensures this happens early enough.
+func refreshInterval(ttl time.Duration) time.Duration {
+	return min(max(ttl/10, time.Second), time.Minute)
+}
+
 func (r *Rooms) Count() (int, string) {
 	timeout := time.After(5 * time.Second)
 
@@ -127,16 +271,94 @@ func (r *Rooms) Count() (int, string) {
 	}
 }
 
+// Sessions returns all active sessions including the stats reported by the clients.
+func (r *Rooms) Sessions() ([]SessionInfo, error) {
+	timeout := time.After(5 * time.Second)
+
+	e := ListSessions{Response: make(chan []SessionInfo, 1)}
+	select {
+	case r.Incoming <- ClientMessage{SkipConnectedCheck: true, Incoming: &e}:
+	case <-timeout:
+		return nil, errors.New("main loop didn't accept a message within 5 second")
+	}
+	select {
+	case sessions := <-e.Response:
+		return sessions, nil
+	case <-timeout:
+		return nil, errors.New("main loop didn't respond to a message within 5 second")
+	}
+}
+
+// Shutdown announces the shutdown to all users, waits for the drain period or until all rooms are closed and closes
+// all rooms and connections afterwards. New websocket connections are rejected and the main loop is stopped.
+func (r *Rooms) Shutdown(drain time.Duration) {
+	r.connectionsLock.Lock()
+	r.draining.Store(true)
+	r.connectionsLock.Unlock()
+
+	empty := make(chan struct{})
+	if !r.send(&AnnounceShutdown{Drain: drain, Empty: empty}) {
+		return
+	}
+	log.Info().Str("drain", drain.String()).Msg("Waiting for users to leave")
+	select {
+	case <-empty:
+		log.Info().Msg("All rooms are closed")
+	case <-time.After(drain):
+	}
+	if !r.send(&Shutdown{}) {
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		r.connections.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(writeWait):
+		log.Warn().Str("timeout", writeWait.String()).Msg("websocket connections didn't close in time")
+	}
+}
+
+// Draining returns true once the shutdown started.
+func (r *Rooms) Draining() bool {
+	return r.draining.Load()
+}
+
+func (r *Rooms) send(e Event) bool {
+	select {
+	case r.Incoming <- ClientMessage{SkipConnectedCheck: true, Incoming: e}:
+		return true
+	case <-time.After(5 * time.Second):
+		log.Warn().Interface("event", fmt.Sprintf("%T", e)).Msg("main loop didn't accept a message within 5 second")
+		return false
+	}
+}
+
 func (r *Rooms) closeRoom(roomID string) {
 	room, ok := r.Rooms[roomID]
 	if !ok {
 		return
 	}
+	mode := modeLabel(room.Mode)
 	usersLeftTotal.Add(float64(len(room.Users)))
+	usersCurrent.WithLabelValues(mode).Sub(float64(len(room.Users)))
 	for id := range room.Sessions {
 		room.closeSession(r, id)
 	}
+	for _, user := range room.Users {
+		if user.Streaming {
+			streamersCurrent.WithLabelValues(mode).Dec()
+		}
+		room.audit(audit.RoomLeave, user)
+	}
 
 	delete(r.Rooms, roomID)
 	roomsClosedTotal.Inc()
+	roomsCurrent.WithLabelValues(mode).Dec()
+	roomLifetime.WithLabelValues(mode).Observe(time.Since(room.Created).Seconds())
+	audit.Log(audit.Entry{Event: audit.RoomClose, Room: roomID})
+	r.notify(webhook.RoomClosed, room, nil)
 }
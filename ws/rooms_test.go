@@ -4,16 +4,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/rs/xid"
+	"github.com/screego/server/config"
+	"github.com/screego/server/config/ipdns"
+	"github.com/stretchr/testify/assert"
 )
 
 const SERVER = "ws://localhost:5050/stream"
 
+func TestShutdown_endsDrainWithoutRooms(t *testing.T) {
+	rooms := NewRooms(nil, nil, config.NewLive(config.Config{
+		TurnIPProvider:    &ipdns.Static{},
+		TurnCredentialTTL: time.Minute,
+	}, ""))
+	go rooms.Start()
+
+	start := time.Now()
+	rooms.Shutdown(time.Minute)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	select {
+	case <-rooms.done:
+	case <-time.After(time.Second):
+		t.Fatal("main loop should be stopped")
+	}
+
+	recorder := httptest.NewRecorder()
+	rooms.Upgrade(recorder, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.Equal(t, 503, recorder.Code)
+}
+
 func TestMultipleClients(t *testing.T) {
 	t.Skip("only for manual testing")
 	r := rand.New(rand.NewSource(time.Now().UnixMicro()))
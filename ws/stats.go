@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"time"
+)
+
+// PeerStats aggregates the stats reported by one peer of a session.
+type PeerStats struct {
+	CandidateType string    `json:"candidateType"`
+	Reports       int       `json:"reports"`
+	Updated       time.Time `json:"updated"`
+
+	RTT        float64 `json:"rtt"`
+	Bitrate    float64 `json:"bitrate"`
+	PacketLoss float64 `json:"packetLoss"`
+	FrameRate  float64 `json:"frameRate"`
+
+	AvgRTT        float64 `json:"avgRtt"`
+	AvgBitrate    float64 `json:"avgBitrate"`
+	AvgPacketLoss float64 `json:"avgPacketLoss"`
+	AvgFrameRate  float64 `json:"avgFrameRate"`
+}
+
+func (s *PeerStats) add(e *Stats) {
+	s.Reports++
+	s.Updated = time.Now()
+	s.CandidateType = e.CandidateType
+	s.RTT, s.Bitrate, s.PacketLoss, s.FrameRate = e.RTT, e.Bitrate, e.PacketLoss, e.FrameRate
+
+	n := float64(s.Reports)
+	s.AvgRTT += (e.RTT - s.AvgRTT) / n
+	s.AvgBitrate += (e.Bitrate - s.AvgBitrate) / n
+	s.AvgPacketLoss += (e.PacketLoss - s.AvgPacketLoss) / n
+	s.AvgFrameRate += (e.FrameRate - s.AvgFrameRate) / n
+}
+
+// candidateType returns the most indirect candidate type reported by the peers of the session, a session is
+// relayed if at least one peer uses TURN. Empty if no stats were reported.
+func (s *RoomSession) candidateType() string {
+	result := ""
+	for _, stats := range []*PeerStats{s.HostStats, s.ClientStats} {
+		if stats != nil && candidateRank(stats.CandidateType) > candidateRank(result) {
+			result = stats.CandidateType
+		}
+	}
+	return result
+}
+
+func candidateRank(candidateType string) int {
+	switch candidateType {
+	case "relay":
+		return 4
+	case "prflx":
+		return 3
+	case "srflx":
+		return 2
+	case "host":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// observeStats records the aggregated stats of a closed session.
+func (s *RoomSession) observeStats() {
+	if candidateType := s.candidateType(); candidateType != "" {
+		sessionsByCandidateType.WithLabelValues(candidateType).Dec()
+		sessionCandidateTypeTotal.WithLabelValues(candidateType).Inc()
+	} else {
+		sessionCandidateTypeTotal.WithLabelValues("unknown").Inc()
+	}
+
+	for role, stats := range map[string]*PeerStats{"host": s.HostStats, "client": s.ClientStats} {
+		if stats == nil {
+			continue
+		}
+		sessionRTT.WithLabelValues(role).Observe(stats.AvgRTT)
+		sessionBitrate.WithLabelValues(role).Observe(stats.AvgBitrate)
+		sessionPacketLoss.WithLabelValues(role).Observe(stats.AvgPacketLoss)
+		sessionFrameRate.WithLabelValues(role).Observe(stats.AvgFrameRate)
+	}
+}
@@ -0,0 +1,35 @@
+package ws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerStats_add(t *testing.T) {
+	stats := &PeerStats{}
+	stats.add(&Stats{CandidateType: "srflx", RTT: 0.1, Bitrate: 1000, PacketLoss: 0.1, FrameRate: 30})
+	stats.add(&Stats{CandidateType: "relay", RTT: 0.3, Bitrate: 3000, PacketLoss: 0, FrameRate: 10})
+
+	assert.Equal(t, 2, stats.Reports)
+	assert.Equal(t, "relay", stats.CandidateType)
+	assert.Equal(t, 0.3, stats.RTT)
+	assert.InDelta(t, 0.2, stats.AvgRTT, 0.0001)
+	assert.InDelta(t, 2000, stats.AvgBitrate, 0.0001)
+	assert.InDelta(t, 0.05, stats.AvgPacketLoss, 0.0001)
+	assert.InDelta(t, 20, stats.AvgFrameRate, 0.0001)
+}
+
+func TestRoomSession_candidateType(t *testing.T) {
+	session := &RoomSession{}
+	assert.Equal(t, "", session.candidateType())
+
+	session.HostStats = &PeerStats{CandidateType: "host"}
+	assert.Equal(t, "host", session.candidateType())
+
+	session.ClientStats = &PeerStats{CandidateType: "relay"}
+	assert.Equal(t, "relay", session.candidateType())
+
+	session.HostStats.CandidateType = "srflx"
+	assert.Equal(t, "relay", session.candidateType())
+}
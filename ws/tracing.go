@@ -0,0 +1,58 @@
+package ws
+
+import (
+	"context"
+
+	"github.com/rs/xid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/screego/server/ws")
+
+// traceContext returns the context containing the span of the websocket connection. Events sent by the server
+// itself don't have a connection.
+func (c ClientInfo) traceContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+func (r *Rooms) traceAttributes(info ClientInfo) []attribute.KeyValue {
+	if info.ID.IsNil() {
+		return nil
+	}
+	attrs := []attribute.KeyValue{attribute.String("connection", info.ID.String())}
+	room, err := r.CurrentRoom(info)
+	if err != nil {
+		return attrs
+	}
+	attrs = append(attrs, attribute.String("room", room.ID))
+	if user, ok := room.Users[info.ID]; ok {
+		attrs = append(attrs, attribute.String("user", user.Name))
+	}
+	return attrs
+}
+
+func (r *Room) startSessionSpan(id xid.ID, session *RoomSession) {
+	host, client := r.Users[session.Host], r.Users[session.Client]
+	// a session is traced separately, it lives longer than the event which created it.
+	_, session.span = tracer.Start(context.Background(), "session", trace.WithNewRoot(), trace.WithAttributes(
+		attribute.String("session", id.String()),
+		attribute.String("room", r.ID),
+		attribute.String("mode", string(r.Mode)),
+		attribute.String("host", host.Name),
+		attribute.String("host.account", host.Account),
+		attribute.String("client", client.Name),
+		attribute.String("client.account", client.Account),
+	))
+}
+
+// traceEvent records a signaling message or state change of the session.
+func (s *RoomSession) traceEvent(name string) {
+	if s.span != nil {
+		s.span.AddEvent(name)
+	}
+}